@@ -0,0 +1,54 @@
+package limiter
+
+import (
+	"context"
+	"time"
+)
+
+// Outcome is the decision on one request counted by Limiter.Take. It
+// carries what an adapter for another framework needs to answer the
+// request the way the built-in middlewares do.
+type Outcome struct {
+	limiter *Limiter
+	key     string
+	o       outcome
+}
+
+// Take counts one request for key, applying the FailurePolicy, the hooks
+// and the shadow limits like the built-in middlewares. request describes
+// the request to the hooks and may be nil.
+func (l *Limiter) Take(ctx context.Context, key string, request func() RequestInfo) *Outcome {
+	return &Outcome{limiter: l, key: key, o: l.take(ctx, key, request)}
+}
+
+// Err returns the store error left after the FailurePolicy. The request
+// was not decided and should be answered by the adapter's ErrorHandler.
+func (o *Outcome) Err() error {
+	return o.o.err
+}
+
+// Rejected reports whether the request must be denied, which a DryRun
+// limiter never does.
+func (o *Outcome) Rejected() bool {
+	return o.o.rejected
+}
+
+// RetryAfter returns how long until the key may be admitted again.
+func (o *Outcome) RetryAfter() time.Duration {
+	return max(o.o.reset.Sub(o.limiter.clock.Now()), 0)
+}
+
+// SetHeaders sends the rate limit headers of mode through set, and
+// Retry-After when the request is rejected.
+func (o *Outcome) SetHeaders(set func(key, value string), mode HeaderMode) {
+	o.limiter.writeHeaders(set, mode, o.o)
+	if o.o.rejected {
+		set("Retry-After", o.limiter.retryAfter(o.o.reset))
+	}
+}
+
+// Rollback stops counting the request, for adapters skipping successful
+// requests.
+func (o *Outcome) Rollback(ctx context.Context) error {
+	return o.limiter.rollback(ctx, o.key, o.o)
+}
@@ -0,0 +1,215 @@
+// Package admin serves an HTTP API to inspect and adjust the keys of a
+// limiter at runtime. The handler can be mounted on any router; every
+// request must pass the caller-supplied AuthFunc.
+//
+//	handler := admin.NewHandler(l, func(r *http.Request) bool {
+//		return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) == 1
+//	})
+//	mux.Handle("/admin/ratelimit/", http.StripPrefix("/admin/ratelimit", handler))
+//
+// Routes, relative to the mount point:
+//
+//	GET    /keys/{key}        status of key
+//	DELETE /keys/{key}        reset the counters of key
+//	POST   /overrides/{key}   {"limit": 500, "ttl": "1h"} overrides the limit of key
+//	DELETE /overrides/{key}   remove the override of key
+//	GET    /limited?n=10      keys out of requests, the most denied first
+//
+// The limiter's store must implement limiter.AdminStore, as MemoryStore and
+// the Store of the redis module do. Otherwise every route answers 501 Not Implemented.
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NarmadaWeb/limiter/v3"
+)
+
+const (
+	// DefaultTop is the number of keys /limited returns without ?n=
+	DefaultTop = 10
+	// MaxTop caps ?n= on /limited
+	MaxTop = 1000
+)
+
+// AuthFunc reports whether r may use the admin API.
+type AuthFunc func(r *http.Request) bool
+
+// Status is the JSON form of limiter.KeyStatus.
+type Status struct {
+	Key       string    `json:"key"`
+	Algorithm string    `json:"algorithm"`
+	Limit     int       `json:"limit"`
+	Count     int       `json:"count"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+	Denied    int       `json:"denied"`
+	Override  *Override `json:"override,omitempty"`
+}
+
+// Override is a temporary limit of a key.
+type Override struct {
+	Limit int       `json:"limit"`
+	Until time.Time `json:"until"`
+}
+
+// OverrideRequest is the body of POST /overrides/{key}.
+type OverrideRequest struct {
+	Limit int `json:"limit"`
+	// TTL is a duration such as "30m"
+	TTL string `json:"ttl"`
+}
+
+type handler struct {
+	limiter *limiter.Limiter
+	auth    AuthFunc
+	mux     *http.ServeMux
+}
+
+// NewHandler returns the admin API of l. Requests for which auth returns
+// false are answered 401 Unauthorized; a nil auth rejects every request.
+func NewHandler(l *limiter.Limiter, auth AuthFunc) http.Handler {
+	h := &handler{limiter: l, auth: auth, mux: http.NewServeMux()}
+	h.mux.HandleFunc("GET /keys/{key...}", h.status)
+	h.mux.HandleFunc("DELETE /keys/{key...}", h.reset)
+	h.mux.HandleFunc("POST /overrides/{key...}", h.override)
+	h.mux.HandleFunc("DELETE /overrides/{key...}", h.removeOverride)
+	h.mux.HandleFunc("GET /limited", h.limited)
+	return h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.auth == nil || !h.auth(r) {
+		writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+	if _, ok := h.limiter.Store().(limiter.AdminStore); !ok {
+		writeError(w, http.StatusNotImplemented, fmt.Errorf("%T does not support the admin API", h.limiter.Store()))
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *handler) store() limiter.AdminStore {
+	store, _ := h.limiter.Store().(limiter.AdminStore)
+	return store
+}
+
+func (h *handler) status(w http.ResponseWriter, r *http.Request) {
+	cfg := h.limiter.Config()
+	status, err := h.store().Status(r.Context(), r.PathValue("key"), cfg.MaxRequests, cfg.Window, cfg.Algorithm)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toStatus(status))
+}
+
+func (h *handler) reset(w http.ResponseWriter, r *http.Request) {
+	if err := h.store().Reset(r.Context(), r.PathValue("key")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) override(w http.ResponseWriter, r *http.Request) {
+	var req OverrideRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+	ttl, err := time.ParseDuration(req.TTL)
+	if err != nil || ttl <= 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("ttl must be a positive duration such as \"30m\", got %q", req.TTL))
+		return
+	}
+	if req.Limit <= 0 {
+		writeError(w, http.StatusBadRequest, errors.New("limit must be positive"))
+		return
+	}
+
+	key := r.PathValue("key")
+	if err := h.store().Override(r.Context(), key, req.Limit, ttl); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	h.status(w, r)
+}
+
+func (h *handler) removeOverride(w http.ResponseWriter, r *http.Request) {
+	if err := h.store().Override(r.Context(), r.PathValue("key"), 0, 0); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) limited(w http.ResponseWriter, r *http.Request) {
+	n := DefaultTop
+	if v := r.URL.Query().Get("n"); v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil || n <= 0 || n > MaxTop {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("n must be between 1 and %d", MaxTop))
+			return
+		}
+	}
+
+	cfg := h.limiter.Config()
+	limited, err := h.store().TopLimited(r.Context(), n, cfg.MaxRequests, cfg.Window, cfg.Algorithm)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	statuses := make([]Status, len(limited))
+	for i, s := range limited {
+		statuses[i] = toStatus(s)
+	}
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+func toStatus(s limiter.KeyStatus) Status {
+	status := Status{
+		Key:       s.Key,
+		Algorithm: string(s.Algorithm),
+		Limit:     s.Limit,
+		Count:     s.Count,
+		Remaining: s.Remaining,
+		Reset:     s.Reset.UTC(),
+		Denied:    s.Denied,
+	}
+	if s.Override > 0 {
+		status.Override = &Override{Limit: s.Override, Until: s.OverrideUntil.UTC()}
+	}
+	return status
+}
+
+// writeStoreError maps store errors to a status code: unsupported
+// operations are 501, an unreachable store 503.
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errors.ErrUnsupported):
+		writeError(w, http.StatusNotImplemented, err)
+	case errors.Is(err, limiter.ErrStorage), errors.Is(err, limiter.ErrCircuitOpen):
+		writeError(w, http.StatusServiceUnavailable, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,183 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Algorithm names a rate limiting algorithm: one of the constants below or
+// a strategy added with RegisterStrategy. It is a string type so that
+// configuration files can name it, which means any string converts to it:
+// a misspelled algorithm is caught when the Config is validated by New or
+// UpdateConfig, with an error matching ErrInvalidAlgorithm.
+type Algorithm string
+
+const (
+	// TokenBucket allows bursts and refills at a steady rate.
+	TokenBucket Algorithm = "token-bucket"
+	// SlidingWindow counts the exact requests of the last window.
+	SlidingWindow Algorithm = "sliding-window"
+	// FixedWindow counts requests per fixed interval.
+	FixedWindow Algorithm = "fixed-window"
+)
+
+var builtinAlgorithms = []Algorithm{TokenBucket, SlidingWindow, FixedWindow}
+
+// Strategy is a custom rate limiting algorithm. It keeps its per-key state
+// as opaque bytes, so it runs on any StateStore, including MemoryStore and
+// the Store of the redis module.
+//
+// Take and Rollback may be called more than once for a single request when
+// a store retries a conflicting update, so they must not have side effects.
+type Strategy interface {
+	// Name is the Algorithm that selects the strategy in Config.
+	Name() Algorithm
+	// Take grants up to n requests given the current state of a key, which
+	// is nil when the key has none.
+	Take(state []byte, now time.Time, n, maxRequests int, window time.Duration) StrategyResult
+	// Rollback returns one request to state and returns the new state.
+	// Returning nil deletes the state.
+	Rollback(state []byte, now time.Time) []byte
+}
+
+// StrategyResult is the outcome of Strategy.Take.
+type StrategyResult struct {
+	Granted   int
+	Remaining int
+	Reset     time.Time
+	// State is stored back for the key; nil deletes it.
+	State []byte
+	// TTL is how long State is kept. Zero keeps the current expiry, so new
+	// state with a zero TTL is not stored.
+	TTL time.Duration
+}
+
+// StateStore is implemented by stores that can run custom strategies.
+// UpdateState atomically replaces the state algorithm keeps for key with
+// the result of fn; fn may be called more than once. A nil state means the
+// key has none, and returning nil deletes it.
+type StateStore interface {
+	UpdateState(ctx context.Context, key string, algorithm Algorithm, fn func(state []byte) ([]byte, time.Duration)) error
+}
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = make(map[Algorithm]Strategy)
+)
+
+// RegisterStrategy makes s available under s.Name(). Built-in algorithms
+// cannot be replaced and a name can only be registered once.
+func RegisterStrategy(s Strategy) error {
+	name := s.Name()
+	if name == "" || slices.Contains(builtinAlgorithms, name) {
+		return fmt.Errorf("%w: cannot register %q", ErrInvalidAlgorithm, name)
+	}
+
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+
+	if _, ok := strategies[name]; ok {
+		return fmt.Errorf("%w: %q is already registered", ErrInvalidAlgorithm, name)
+	}
+	strategies[name] = s
+	return nil
+}
+
+// LookupStrategy returns the strategy registered under name.
+func LookupStrategy(name Algorithm) (Strategy, bool) {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+
+	s, ok := strategies[name]
+	return s, ok
+}
+
+// Algorithms returns the built-in algorithms followed by every registered
+// strategy, sorted by name.
+func Algorithms() []Algorithm {
+	strategiesMu.RLock()
+	custom := make([]Algorithm, 0, len(strategies))
+	for name := range strategies {
+		custom = append(custom, name)
+	}
+	strategiesMu.RUnlock()
+
+	slices.Sort(custom)
+	return append(slices.Clone(builtinAlgorithms), custom...)
+}
+
+// Valid reports whether a is a built-in algorithm or a registered strategy.
+func (a Algorithm) Valid() bool {
+	if slices.Contains(builtinAlgorithms, a) {
+		return true
+	}
+	_, ok := LookupStrategy(a)
+	return ok
+}
+
+// Builtin reports whether a is one of the algorithms stores implement
+// natively rather than through a registered Strategy.
+func (a Algorithm) Builtin() bool {
+	return slices.Contains(builtinAlgorithms, a)
+}
+
+// unknownAlgorithm describes an invalid algorithm and lists the valid ones.
+func unknownAlgorithm(a Algorithm) string {
+	valid := make([]string, 0, len(builtinAlgorithms))
+	for _, v := range Algorithms() {
+		valid = append(valid, string(v))
+	}
+	return fmt.Sprintf("unknown algorithm %q, want %s", a, strings.Join(valid, ", "))
+}
+
+// Strategies returns every registered strategy, sorted by name. Stores use
+// it to reach the state they keep for custom algorithms.
+func Strategies() []Strategy {
+	strategiesMu.RLock()
+	list := make([]Strategy, 0, len(strategies))
+	for _, s := range strategies {
+		list = append(list, s)
+	}
+	strategiesMu.RUnlock()
+
+	slices.SortFunc(list, func(a, b Strategy) int { return strings.Compare(string(a.Name()), string(b.Name())) })
+	return list
+}
+
+// strategyFor returns the strategy for a non built-in algorithm, or
+// ErrInvalidAlgorithm when none is registered.
+func strategyFor(algorithm Algorithm) (Strategy, error) {
+	s, ok := LookupStrategy(algorithm)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAlgorithm, algorithm)
+	}
+	return s, nil
+}
+
+// TakeWithStrategy runs s against the state store keeps for key. Custom
+// stores call it from TakeN for algorithms they do not implement natively.
+func TakeWithStrategy(ctx context.Context, store StateStore, s Strategy, key string, n, maxRequests int, window time.Duration, now time.Time) (int, int, time.Time, error) {
+	var result StrategyResult
+	err := store.UpdateState(ctx, key, s.Name(), func(state []byte) ([]byte, time.Duration) {
+		result = s.Take(state, now, n, maxRequests, window)
+		return result.State, result.TTL
+	})
+	if err != nil {
+		return 0, 0, now.Add(window), err
+	}
+	return result.Granted, result.Remaining, result.Reset, nil
+}
+
+// RollbackWithStrategy returns one request to the state s keeps for key.
+func RollbackWithStrategy(ctx context.Context, store StateStore, s Strategy, key string, now time.Time) error {
+	return store.UpdateState(ctx, key, s.Name(), func(state []byte) ([]byte, time.Duration) {
+		if state == nil {
+			return nil, 0
+		}
+		return s.Rollback(state, now), 0
+	})
+}
@@ -0,0 +1,85 @@
+// Package beego adapts the limiter to Beego as a filter chain. It is a
+// module of its own so that only its users depend on Beego.
+package beego
+
+import (
+	"net/http"
+
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/beego/beego/v2/server/web"
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+type Config struct {
+	KeyGenerator        func(ctx *context.Context) string
+	LimitReachedHandler web.FilterFunc
+	ErrorHandler        func(ctx *context.Context, err error)
+	Skipsuccessfull     bool
+	// Headers selects the rate limit headers, HeadersXRateLimit by default
+	Headers limiter.HeaderMode
+}
+
+// New creates a Beego filter chain counting requests against l. Install
+// it with web.InsertFilterChain.
+func New(l *limiter.Limiter, cfg Config) web.FilterChain {
+	// Set defaults
+	if cfg.KeyGenerator == nil {
+		cfg.KeyGenerator = func(ctx *context.Context) string {
+			return ctx.Input.IP()
+		}
+	}
+	if cfg.LimitReachedHandler == nil {
+		cfg.LimitReachedHandler = func(ctx *context.Context) {
+			ctx.Output.SetStatus(http.StatusTooManyRequests)
+			_ = ctx.Output.JSON(map[string]string{
+				"error":   "rate limit exceeded",
+				"message": "Too many requests, please try again later",
+			}, false, false)
+		}
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = func(ctx *context.Context, err error) {
+			ctx.Output.SetStatus(http.StatusInternalServerError)
+			_ = ctx.Output.JSON(map[string]string{
+				"error":   "rate limit error",
+				"message": err.Error(),
+			}, false, false)
+		}
+	}
+
+	return func(next web.FilterFunc) web.FilterFunc {
+		return func(ctx *context.Context) {
+			key := cfg.KeyGenerator(ctx)
+
+			o := l.Take(ctx.Request.Context(), key, func() limiter.RequestInfo { return limiter.HTTPRequestInfo(ctx.Request) })
+			if err := o.Err(); err != nil {
+				cfg.ErrorHandler(ctx, err)
+				writeStatus(ctx)
+				return
+			}
+
+			o.SetHeaders(ctx.Output.Header, cfg.Headers)
+
+			if o.Rejected() {
+				cfg.LimitReachedHandler(ctx)
+				writeStatus(ctx)
+				return
+			}
+
+			next(ctx)
+
+			// A status of zero means nothing was written yet: 200 OK.
+			if cfg.Skipsuccessfull && ctx.ResponseWriter.Status < http.StatusBadRequest {
+				_ = o.Rollback(ctx.Request.Context())
+			}
+		}
+	}
+}
+
+// writeStatus sends the status a handler set with Output.SetStatus, as the
+// router does once a request is served.
+func writeStatus(ctx *context.Context) {
+	if ctx.Output.Status != 0 {
+		ctx.ResponseWriter.WriteHeader(ctx.Output.Status)
+	}
+}
@@ -0,0 +1,37 @@
+package beego_test
+
+import (
+	"net/http"
+	"testing"
+
+	limiterbeego "github.com/NarmadaWeb/limiter/beego/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/NarmadaWeb/limiter/v3/limitertest"
+	"github.com/beego/beego/v2/server/web"
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+func TestAdapterConformance(t *testing.T) {
+	limitertest.RunAdapterConformance(t, func(t *testing.T, l *limiter.Limiter, cfg limitertest.AdapterConfig) http.RoundTripper {
+		beegoCfg := limiterbeego.Config{Skipsuccessfull: cfg.Skipsuccessfull, Headers: cfg.Headers}
+		if cfg.KeyHeader != "" {
+			beegoCfg.KeyGenerator = func(ctx *context.Context) string { return ctx.Input.Header(cfg.KeyHeader) }
+		}
+		if cfg.CustomHandlers {
+			beegoCfg.LimitReachedHandler = func(ctx *context.Context) {
+				ctx.Output.SetStatus(limitertest.StatusLimitReached)
+			}
+			beegoCfg.ErrorHandler = func(ctx *context.Context, _ error) {
+				ctx.Output.SetStatus(limitertest.StatusError)
+			}
+		}
+
+		srv := web.NewHttpSever()
+		srv.InsertFilterChain("*", limiterbeego.New(l, beegoCfg))
+		srv.Get("/ok", func(ctx *context.Context) { ctx.Output.SetStatus(http.StatusOK) })
+		srv.Get("/fail", func(ctx *context.Context) { ctx.Output.SetStatus(http.StatusInternalServerError) })
+		// Run builds the filter chains; serving without it needs Init.
+		srv.Handlers.Init()
+		return limitertest.HandlerTransport(srv.Handlers)
+	})
+}
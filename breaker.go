@@ -0,0 +1,351 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a CircuitBreakerStore.
+type BreakerState int
+
+const (
+	// BreakerClosed passes every call to the wrapped store.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects every call with ErrCircuitOpen until the cooldown ends.
+	BreakerOpen
+	// BreakerHalfOpen lets a limited number of probe calls through.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerOptions configures a CircuitBreakerStore.
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// circuit. Defaults to 5.
+	FailureThreshold int
+	// LatencyThreshold counts calls slower than this as failures, even when
+	// they succeed. Zero disables the latency check.
+	LatencyThreshold time.Duration
+	// Timeout bounds every call to the wrapped store. Zero means no timeout.
+	Timeout time.Duration
+	// Cooldown is how long the circuit stays open before probing. Defaults to 10 seconds.
+	Cooldown time.Duration
+	// HalfOpenRequests is the number of probe calls allowed while half-open;
+	// that many successes close the circuit again. Defaults to 1.
+	HalfOpenRequests int
+	// OnStateChange is called after every state transition
+	OnStateChange func(from, to BreakerState)
+	// Clock defaults to the system clock
+	Clock Clock
+}
+
+// CircuitBreakerStore wraps a Store and short-circuits calls while the
+// store is failing or slow, so an outage costs no latency. Combine it with
+// FailOpen or FallbackToLocal to keep serving traffic during incidents.
+type CircuitBreakerStore struct {
+	store Store
+	opts  CircuitBreakerOptions
+
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	probes    int
+	successes int
+}
+
+func NewCircuitBreakerStore(store Store, opts CircuitBreakerOptions) *CircuitBreakerStore {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 5
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = 10 * time.Second
+	}
+	if opts.HalfOpenRequests <= 0 {
+		opts.HalfOpenRequests = 1
+	}
+	opts.Clock = clockOrDefault(opts.Clock)
+	return &CircuitBreakerStore{
+		store: store,
+		opts:  opts,
+	}
+}
+
+// State returns the current state of the circuit.
+func (b *CircuitBreakerStore) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// Unwrap returns the wrapped store.
+func (b *CircuitBreakerStore) Unwrap() Store {
+	return b.store
+}
+
+func (b *CircuitBreakerStore) Take(ctx context.Context, key string, maxRequests int, window time.Duration, algorithm Algorithm) (bool, int, time.Time, error) {
+	var (
+		allowed   bool
+		remaining int
+		reset     time.Time
+	)
+	err := b.call(ctx, func(ctx context.Context) error {
+		var err error
+		allowed, remaining, reset, err = b.store.Take(ctx, key, maxRequests, window, algorithm)
+		return err
+	})
+	return allowed, remaining, reset, err
+}
+
+func (b *CircuitBreakerStore) Rollback(ctx context.Context, key string) error {
+	return b.call(ctx, func(ctx context.Context) error {
+		return b.store.Rollback(ctx, key)
+	})
+}
+
+func (b *CircuitBreakerStore) Get(ctx context.Context, key string) (int, error) {
+	var value int
+	err := b.call(ctx, func(ctx context.Context) error {
+		var err error
+		value, err = b.store.Get(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+func (b *CircuitBreakerStore) Set(ctx context.Context, key string, value int, expiration time.Duration) error {
+	return b.call(ctx, func(ctx context.Context) error {
+		return b.store.Set(ctx, key, value, expiration)
+	})
+}
+
+// TakeN implements BatchStore, in one call to the wrapped store when it is
+// a BatchStore and one Take per request otherwise.
+func (b *CircuitBreakerStore) TakeN(ctx context.Context, key string, n, maxRequests int, window time.Duration, algorithm Algorithm) (int, int, time.Time, error) {
+	var (
+		granted   int
+		remaining int
+		reset     time.Time
+	)
+	err := b.call(ctx, func(ctx context.Context) error {
+		var err error
+		if batch, ok := b.store.(BatchStore); ok {
+			granted, remaining, reset, err = batch.TakeN(ctx, key, n, maxRequests, window, algorithm)
+			return err
+		}
+		for range n {
+			var allowed bool
+			allowed, remaining, reset, err = b.store.Take(ctx, key, maxRequests, window, algorithm)
+			if err != nil || !allowed {
+				return err
+			}
+			granted++
+		}
+		return nil
+	})
+	return granted, remaining, reset, err
+}
+
+// Migrate implements Migrator when the wrapped store does.
+func (b *CircuitBreakerStore) Migrate(ctx context.Context, key string, from, to Algorithm, maxRequests int, window time.Duration) error {
+	migrator, ok := b.store.(Migrator)
+	if !ok {
+		return nil
+	}
+	return b.call(ctx, func(ctx context.Context) error {
+		return migrator.Migrate(ctx, key, from, to, maxRequests, window)
+	})
+}
+
+// Status implements AdminStore when the wrapped store does.
+func (b *CircuitBreakerStore) Status(ctx context.Context, key string, maxRequests int, window time.Duration, algorithm Algorithm) (KeyStatus, error) {
+	admin, err := b.admin()
+	if err != nil {
+		return KeyStatus{}, err
+	}
+	var status KeyStatus
+	err = b.call(ctx, func(ctx context.Context) error {
+		var err error
+		status, err = admin.Status(ctx, key, maxRequests, window, algorithm)
+		return err
+	})
+	return status, err
+}
+
+// Reset implements AdminStore when the wrapped store does.
+func (b *CircuitBreakerStore) Reset(ctx context.Context, key string) error {
+	admin, err := b.admin()
+	if err != nil {
+		return err
+	}
+	return b.call(ctx, func(ctx context.Context) error {
+		return admin.Reset(ctx, key)
+	})
+}
+
+// Override implements AdminStore when the wrapped store does.
+func (b *CircuitBreakerStore) Override(ctx context.Context, key string, maxRequests int, ttl time.Duration) error {
+	admin, err := b.admin()
+	if err != nil {
+		return err
+	}
+	return b.call(ctx, func(ctx context.Context) error {
+		return admin.Override(ctx, key, maxRequests, ttl)
+	})
+}
+
+// TopLimited implements AdminStore when the wrapped store does.
+func (b *CircuitBreakerStore) TopLimited(ctx context.Context, n, maxRequests int, window time.Duration, algorithm Algorithm) ([]KeyStatus, error) {
+	admin, err := b.admin()
+	if err != nil {
+		return nil, err
+	}
+	var limited []KeyStatus
+	err = b.call(ctx, func(ctx context.Context) error {
+		var err error
+		limited, err = admin.TopLimited(ctx, n, maxRequests, window, algorithm)
+		return err
+	})
+	return limited, err
+}
+
+func (b *CircuitBreakerStore) admin() (AdminStore, error) {
+	admin, ok := b.store.(AdminStore)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T is not an AdminStore: %w", ErrStorage, b.store, errors.ErrUnsupported)
+	}
+	return admin, nil
+}
+
+func (b *CircuitBreakerStore) Close() error {
+	if closer, ok := b.store.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// call runs fn against the wrapped store when the circuit allows it and
+// records the outcome. Calls canceled by the caller and calls the store
+// does not support are not recorded: they say nothing about its health.
+func (b *CircuitBreakerStore) call(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !b.allow() {
+		return ErrCircuitOpen
+	}
+
+	callCtx := ctx
+	if b.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, b.opts.Timeout)
+		defer cancel()
+	}
+
+	start := b.opts.Clock.Now()
+	err := fn(callCtx)
+	if err != nil && (ctx.Err() != nil || errors.Is(err, errors.ErrUnsupported)) {
+		b.forget()
+		return err
+	}
+	slow := b.opts.LatencyThreshold > 0 && b.opts.Clock.Now().Sub(start) > b.opts.LatencyThreshold
+	b.record(err == nil && !slow)
+	return err
+}
+
+func (b *CircuitBreakerStore) allow() bool {
+	b.mu.Lock()
+	from := b.state
+
+	allowed := true
+	switch b.state {
+	case BreakerOpen:
+		if b.opts.Clock.Now().Sub(b.openedAt) < b.opts.Cooldown {
+			allowed = false
+			break
+		}
+		b.state = BreakerHalfOpen
+		b.probes = 1
+		b.successes = 0
+	case BreakerHalfOpen:
+		if b.probes >= b.opts.HalfOpenRequests {
+			allowed = false
+			break
+		}
+		b.probes++
+	}
+
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	return allowed
+}
+
+func (b *CircuitBreakerStore) record(success bool) {
+	b.mu.Lock()
+	from := b.state
+
+	switch b.state {
+	case BreakerClosed:
+		if success {
+			b.failures = 0
+			break
+		}
+		b.failures++
+		if b.failures >= b.opts.FailureThreshold {
+			b.open()
+		}
+	case BreakerHalfOpen:
+		if !success {
+			b.open()
+			break
+		}
+		b.successes++
+		if b.successes >= b.opts.HalfOpenRequests {
+			b.state = BreakerClosed
+			b.failures = 0
+		}
+	}
+
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// forget hands back the probe taken by a call that ended without an outcome.
+func (b *CircuitBreakerStore) forget() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// open must be called with b.mu held.
+func (b *CircuitBreakerStore) open() {
+	b.state = BreakerOpen
+	b.openedAt = b.opts.Clock.Now()
+	b.failures = 0
+}
+
+func (b *CircuitBreakerStore) notify(from, to BreakerState) {
+	if from != to && b.opts.OnStateChange != nil {
+		b.opts.OnStateChange(from, to)
+	}
+}
@@ -0,0 +1,265 @@
+package limiter
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// TransportConfig configures the http.RoundTripper returned by
+// Limiter.Transport.
+type TransportConfig struct {
+	// KeyGenerator extracts the key of an outbound request, HostKey by default
+	KeyGenerator func(r *http.Request) string
+	// Wait blocks a request over the limit until it is admitted or its
+	// context is done. Otherwise RoundTrip fails fast with a
+	// *LimitExceededError.
+	Wait bool
+	// AdaptToUpstream reads the rate limit headers of every response. A
+	// Retry-After, or a remaining count of zero with its reset, pauses the
+	// key on this Limiter; a remaining count lower than the limiter's is
+	// taken from the store, so every worker sharing a Redis store holds
+	// back together.
+	AdaptToUpstream bool
+}
+
+// HostKey keys outbound requests by their destination host and port.
+func HostKey(r *http.Request) string {
+	return r.URL.Host
+}
+
+// Transport wraps next, http.DefaultTransport if nil, so that every
+// request it sends is counted against the limit of its key first.
+func (l *Limiter) Transport(next http.RoundTripper, cfg TransportConfig) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if cfg.KeyGenerator == nil {
+		cfg.KeyGenerator = HostKey
+	}
+	return &transport{limiter: l, next: next, cfg: cfg}
+}
+
+type transport struct {
+	limiter *Limiter
+	next    http.RoundTripper
+	cfg     TransportConfig
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	key := t.cfg.KeyGenerator(r)
+	o, err := t.limiter.Acquire(r.Context(), key, t.cfg.Wait, func() RequestInfo { return HTTPRequestInfo(r) })
+	if err != nil {
+		// A RoundTripper always closes the request body.
+		if r.Body != nil {
+			_ = r.Body.Close()
+		}
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(r)
+	if err == nil && t.cfg.AdaptToUpstream {
+		o.Adapt(r.Context(), t.limiter.ParseUpstreamQuota(resp.Header.Get))
+	}
+	return resp, err
+}
+
+// Wait blocks until a request for key is admitted, sleeping through
+// denials. It returns ctx.Err() if ctx is done first, and a
+// *LimitExceededError without waiting when ctx's deadline comes before
+// the request could be admitted.
+func (l *Limiter) Wait(ctx context.Context, key string) error {
+	_, err := l.acquire(ctx, key, true, nil)
+	return err
+}
+
+// Acquire takes one request for key on behalf of a client, such as the
+// gRPC client interceptors of the grpc module. A denied request fails with
+// a *LimitExceededError unless wait is set, in which case Acquire sleeps
+// until the reset and tries again. Call Adapt on the Outcome with the
+// quota the upstream reported in its response.
+func (l *Limiter) Acquire(ctx context.Context, key string, wait bool, request func() RequestInfo) (*Outcome, error) {
+	o, err := l.acquire(ctx, key, wait, request)
+	if err != nil {
+		return nil, err
+	}
+	return &Outcome{limiter: l, key: key, o: o}, nil
+}
+
+func (l *Limiter) acquire(ctx context.Context, key string, wait bool, request func() RequestInfo) (outcome, error) {
+	for {
+		o := outcome{state: l.state.Load()}
+		now := l.clock.Now()
+		reset := l.pausedUntil(key, now)
+		if !reset.After(now) {
+			o = l.take(ctx, key, request)
+			if o.err != nil {
+				return o, o.err
+			}
+			if !o.rejected {
+				return o, nil
+			}
+			reset = o.reset
+		}
+
+		delay := max(reset.Sub(l.clock.Now()), 0)
+		exceeded := &LimitExceededError{
+			Key:        key,
+			Limit:      o.state.config.MaxRequests,
+			Remaining:  o.remaining,
+			Reset:      reset,
+			RetryAfter: delay,
+		}
+		if !wait {
+			return o, exceeded
+		}
+		if deadline, ok := ctx.Deadline(); ok && deadline.Sub(l.clock.Now()) < delay {
+			return o, exceeded
+		}
+
+		// Never spin when the store reports a reset already passed.
+		timer := time.NewTimer(max(delay, time.Millisecond))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return o, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// pauseSweepInterval is how often pause drops the expired pauses of keys
+// that were not requested again.
+const pauseSweepInterval = time.Minute
+
+// pause holds back every request for key until d has passed, unless it is
+// paused for longer already.
+func (l *Limiter) pause(key string, d time.Duration) {
+	now := l.clock.Now()
+	until := now.Add(d)
+	if prev, ok := l.pauses.Load(key); ok && !until.After(prev.(time.Time)) {
+		return
+	}
+	l.pauses.Store(key, until)
+	l.sweepPauses(now)
+}
+
+// sweepPauses drops expired pauses, at most once per pauseSweepInterval.
+func (l *Limiter) sweepPauses(now time.Time) {
+	next := l.pauseSweep.Load()
+	if now.UnixNano() < next || !l.pauseSweep.CompareAndSwap(next, now.Add(pauseSweepInterval).UnixNano()) {
+		return
+	}
+	l.pauses.Range(func(key, v any) bool {
+		if !v.(time.Time).After(now) {
+			l.pauses.CompareAndDelete(key, v)
+		}
+		return true
+	})
+}
+
+// pausedUntil returns when the pause of key ends, the zero time if it is
+// not paused. Pauses do not apply in DryRun.
+func (l *Limiter) pausedUntil(key string, now time.Time) time.Time {
+	v, ok := l.pauses.Load(key)
+	if !ok {
+		return time.Time{}
+	}
+	until := v.(time.Time)
+	if !until.After(now) {
+		l.pauses.CompareAndDelete(key, v)
+		return time.Time{}
+	}
+	if l.state.Load().config.DryRun {
+		return time.Time{}
+	}
+	return until
+}
+
+// UpstreamQuota is what an upstream reported about its own limit.
+type UpstreamQuota struct {
+	// Remaining is -1 when the upstream did not say
+	Remaining int
+	// RetryAfter is how long the upstream asked to wait, if at all
+	RetryAfter time.Duration
+}
+
+// ParseUpstreamQuota reads Retry-After and the RateLimit-* or
+// X-RateLimit-* headers through get. X-RateLimit-Reset is read as a Unix
+// time when it is one, as seconds from now otherwise.
+func (l *Limiter) ParseUpstreamQuota(get func(key string) string) UpstreamQuota {
+	now := l.clock.Now()
+	q := UpstreamQuota{Remaining: -1}
+	if v := get("Retry-After"); v != "" {
+		if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+			q.RetryAfter = time.Duration(seconds) * time.Second
+		} else if at, err := http.ParseTime(v); err == nil {
+			q.RetryAfter = at.Sub(now)
+		}
+	}
+
+	reset := get("RateLimit-Reset")
+	if v := get("RateLimit-Remaining"); v != "" {
+		q.Remaining = parseCount(v)
+	} else if v := get("X-RateLimit-Remaining"); v != "" {
+		q.Remaining = parseCount(v)
+		reset = get("X-RateLimit-Reset")
+	}
+	if q.Remaining == 0 && q.RetryAfter <= 0 && reset != "" {
+		if seconds, err := strconv.ParseInt(reset, 10, 64); err == nil {
+			// A billion seconds from now would be over 31 years.
+			if seconds > 1_000_000_000 {
+				q.RetryAfter = time.Unix(seconds, 0).Sub(now)
+			} else {
+				q.RetryAfter = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	if q.RetryAfter > 0 && q.Remaining < 0 {
+		q.Remaining = 0
+	}
+	return q
+}
+
+// parseCount parses a non-negative count, -1 if v is not one.
+func parseCount(v string) int {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return -1
+	}
+	return n
+}
+
+// Adapt brings the key of o in line with the quota an upstream reported
+// after the request: it pauses the key for q.RetryAfter and takes what
+// the limiter has left beyond q.Remaining from the store, where every
+// Limiter sharing it sees the difference.
+func (o *Outcome) Adapt(ctx context.Context, q UpstreamQuota) {
+	o.limiter.adapt(ctx, o.key, o.o, q)
+}
+
+func (l *Limiter) adapt(ctx context.Context, key string, o outcome, q UpstreamQuota) {
+	if q.RetryAfter > 0 {
+		l.pause(key, q.RetryAfter)
+	}
+	if q.Remaining < 0 || !o.allowed || o.degraded {
+		return
+	}
+	excess := o.remaining - q.Remaining
+	if excess <= 0 {
+		return
+	}
+
+	cfg := o.state.config
+	if batch, ok := l.store.(BatchStore); ok {
+		_, _, _, _ = batch.TakeN(ctx, key, excess, cfg.MaxRequests, cfg.Window, cfg.Algorithm)
+		return
+	}
+	for range excess {
+		allowed, _, _, err := l.store.Take(ctx, key, cfg.MaxRequests, cfg.Window, cfg.Algorithm)
+		if err != nil || !allowed {
+			return
+		}
+	}
+}
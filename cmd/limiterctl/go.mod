@@ -0,0 +1,22 @@
+module github.com/NarmadaWeb/limiter/cmd/limiterctl/v3
+
+go 1.24.0
+
+require (
+	github.com/NarmadaWeb/limiter/config/v3 v3.0.0
+	github.com/NarmadaWeb/limiter/redis/v3 v3.0.0
+	github.com/NarmadaWeb/limiter/v3 v3.0.0
+	github.com/alicebob/miniredis/v2 v2.39.0
+	github.com/redis/go-redis/v9 v9.17.2
+	github.com/stretchr/testify v1.11.1
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/pelletier/go-toml/v2 v2.2.4 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/redis/go-redis/v9"
+)
+
+// timeFormat prints millisecond timestamps as the scripts store them.
+const timeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+func (c *cli) inspect(ctx context.Context, args []string) error {
+	fs := c.flags("inspect", "<key>")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	if err := c.connect(ctx); err != nil {
+		return err
+	}
+	key := fs.Arg(0)
+
+	all, _ := algorithms("")
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ALGORITHM\tREDIS KEY\tSTATE\tTTL")
+	for _, algorithm := range all {
+		redisKey := c.store.Key(algorithm, key)
+		state, err := c.state(ctx, redisKey)
+		if err != nil {
+			return err
+		}
+		ttl, err := c.ttl(ctx, redisKey)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", algorithm, redisKey, state, ttl)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	override, err := c.client.Get(ctx, c.store.OverrideKey(key)).Result()
+	switch {
+	case errors.Is(err, redis.Nil):
+		override = "-"
+	case err != nil:
+		return err
+	default:
+		ttl, err := c.ttl(ctx, c.store.OverrideKey(key))
+		if err != nil {
+			return err
+		}
+		override += " for " + ttl
+	}
+	denied, err := c.client.ZScore(ctx, c.store.LimitedKey(), c.stored(key)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	fmt.Fprintf(c.out, "\noverride: %s\ndenied:   %d\n", override, int(denied))
+	return nil
+}
+
+// state describes the value of a counter key for its Redis type.
+func (c *cli) state(ctx context.Context, redisKey string) (string, error) {
+	kind, err := c.client.Type(ctx, redisKey).Result()
+	if err != nil {
+		return "", err
+	}
+	switch kind {
+	case "none":
+		return "-", nil
+	case "string":
+		count, err := c.client.Get(ctx, redisKey).Result()
+		return "count=" + count, err
+	case "hash":
+		bucket, err := c.client.HMGet(ctx, redisKey, "tokens", "lastUpdate", "max").Result()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("tokens=%v max=%v updated=%s", bucket[0], bucket[2], millis(bucket[1])), nil
+	case "zset":
+		hits, err := c.client.ZCard(ctx, redisKey).Result()
+		if err != nil {
+			return "", err
+		}
+		oldest, err := c.client.ZRangeWithScores(ctx, redisKey, 0, 0).Result()
+		if err != nil || len(oldest) == 0 {
+			return fmt.Sprintf("hits=%d", hits), err
+		}
+		newest, err := c.client.ZRangeWithScores(ctx, redisKey, -1, -1).Result()
+		if err != nil || len(newest) == 0 {
+			return "", err
+		}
+		return fmt.Sprintf("hits=%d oldest=%s newest=%s", hits,
+			time.UnixMilli(int64(oldest[0].Score)).UTC().Format(timeFormat),
+			time.UnixMilli(int64(newest[0].Score)).UTC().Format(timeFormat)), nil
+	default:
+		return "unexpected type " + kind, nil
+	}
+}
+
+// ttl formats the time to live of redisKey, "-" when it has none.
+func (c *cli) ttl(ctx context.Context, redisKey string) (string, error) {
+	ttl, err := c.client.PTTL(ctx, redisKey).Result()
+	if err != nil {
+		return "", err
+	}
+	if ttl < 0 {
+		return "-", nil
+	}
+	return ttl.Round(time.Millisecond).String(), nil
+}
+
+// stored returns key as it is written to Redis, hashed when -key-hash is set.
+func (c *cli) stored(key string) string {
+	return strings.TrimPrefix(c.store.Key(limiter.FixedWindow, key), c.store.Prefix()+string(limiter.FixedWindow)+":")
+}
+
+// millis formats a millisecond timestamp read from a hash field.
+func millis(v any) string {
+	s, _ := v.(string)
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return "-"
+	}
+	return time.UnixMilli(ms).UTC().Format(timeFormat)
+}
@@ -0,0 +1,201 @@
+// Command limiterctl operates the limiter state held in Redis. It reads
+// keys laid out by the Store of the redis module as
+// <prefix>[<namespace>:][<name>:]<algorithm>:<key>, so the -prefix,
+// -namespace, -name and -key-hash flags must match the StoreOptions
+// of the limiter being operated.
+//
+// Usage:
+//
+//	limiterctl [flags] inspect <key>
+//	limiterctl [flags] reset [-algorithm a] [-dry-run] <pattern>
+//	limiterctl [flags] take -limit n -window d [-algorithm a] [-n n] [-commit] <key>
+//	limiterctl [flags] top [-algorithm a] [-n n] [-shadow]
+//	limiterctl validate <policy file>
+//	limiterctl simulate -limit n -window d [-algorithm a,b] [-format f] <log file>
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	redisstore "github.com/NarmadaWeb/limiter/redis/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/redis/go-redis/v9"
+)
+
+// errUsage reports a command line error; its usage was already printed.
+var errUsage = errors.New("usage")
+
+const usage = `Usage: limiterctl [flags] <command> [arguments]
+
+Commands:
+  inspect <key>       show the state of key under every algorithm
+  reset <pattern>     delete the keys matching a glob pattern
+  take <key>          simulate a Take, or run it with -commit
+  top                 list the keys with the most requests counted
+  validate <file>     check a policy file of the config package
+  simulate <log>      replay an access log through each algorithm offline
+
+Flags:
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// cli holds the global flags and the connections built from them.
+type cli struct {
+	redisURL  string
+	prefix    string
+	namespace string
+	name      string
+	keyHash   string
+
+	out    io.Writer
+	errOut io.Writer
+	client *redis.Client
+	store  *redisstore.Store
+}
+
+// run executes one command and returns the exit code: 0 on success, 1 on
+// failure and 2 on a command line error.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	c := &cli{out: stdout, errOut: stderr}
+	fs := flag.NewFlagSet("limiterctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&c.redisURL, "redis", envOr("LIMITERCTL_REDIS", "redis://localhost:6379/0"), "Redis URL or host:port, $LIMITERCTL_REDIS")
+	fs.StringVar(&c.prefix, "prefix", redisstore.DefaultPrefix, "key prefix")
+	fs.StringVar(&c.namespace, "namespace", "", "key namespace")
+	fs.StringVar(&c.name, "name", "", "limiter name")
+	fs.StringVar(&c.keyHash, "key-hash", "none", "client key hash: none, sha1 or xxhash")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	commands := map[string]func(ctx context.Context, args []string) error{
+		"inspect":  c.inspect,
+		"reset":    c.reset,
+		"take":     c.take,
+		"top":      c.top,
+		"validate": c.validate,
+		"simulate": c.simulate,
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "limiterctl: unknown command %q\n", fs.Arg(0))
+		fs.Usage()
+		return 2
+	}
+	defer c.close()
+
+	err := cmd(ctx, fs.Args()[1:])
+	switch {
+	case errors.Is(err, errUsage):
+		return 2
+	case err != nil:
+		fmt.Fprintf(stderr, "limiterctl: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// connect opens the Redis connection on first use, so validate and
+// simulate work without one.
+func (c *cli) connect(ctx context.Context) error {
+	if c.store != nil {
+		return nil
+	}
+	var hash redisstore.KeyHash
+	switch c.keyHash {
+	case "", "none":
+		hash = redisstore.KeyHashNone
+	case "sha1":
+		hash = redisstore.KeyHashSHA1
+	case "xxhash":
+		hash = redisstore.KeyHashXXHash
+	default:
+		return fmt.Errorf("unknown key hash %q, want none, sha1 or xxhash", c.keyHash)
+	}
+
+	opts := &redis.Options{Addr: c.redisURL}
+	if strings.Contains(c.redisURL, "://") {
+		var err error
+		if opts, err = redis.ParseURL(c.redisURL); err != nil {
+			return fmt.Errorf("invalid -redis: %w", err)
+		}
+	}
+	c.client = redis.NewClient(opts)
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("%w: %w", limiter.ErrRedisConnection, err)
+	}
+	c.store = redisstore.NewStoreWithOptions(c.client, redisstore.StoreOptions{
+		Prefix:    c.prefix,
+		Namespace: c.namespace,
+		Name:      c.name,
+		KeyHash:   hash,
+	})
+	return nil
+}
+
+func (c *cli) close() {
+	if c.client != nil {
+		_ = c.client.Close()
+	}
+}
+
+// flags returns a FlagSet for a command, printing its usage to stderr.
+func (c *cli) flags(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.errOut)
+	fs.Usage = func() {
+		fmt.Fprintf(c.errOut, "Usage: limiterctl %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses args into fs and checks the number of positional arguments.
+func parse(fs *flag.FlagSet, args []string, positional int) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != positional {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// algorithms parses an -algorithm flag; empty means every built-in one.
+func algorithms(name string) ([]limiter.Algorithm, error) {
+	if name == "" {
+		return []limiter.Algorithm{limiter.FixedWindow, limiter.TokenBucket, limiter.SlidingWindow}, nil
+	}
+	if !limiter.Algorithm(name).Valid() {
+		return nil, fmt.Errorf("%w: %q", limiter.ErrInvalidAlgorithm, name)
+	}
+	return []limiter.Algorithm{limiter.Algorithm(name)}, nil
+}
+
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
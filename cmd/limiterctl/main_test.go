@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	redisstore "github.com/NarmadaWeb/limiter/redis/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runCtl(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func seed(t *testing.T) (*miniredis.Miniredis, *redisstore.Store) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	store := redisstore.NewStoreWithOptions(client, redisstore.StoreOptions{Namespace: "shop"})
+
+	ctx := context.Background()
+	for key, n := range map[string]int{"alice": 3, "bob": 1, "carol": 2} {
+		_, _, _, err := store.TakeN(ctx, key, n, 3, time.Minute, limiter.FixedWindow)
+		require.NoError(t, err)
+	}
+	_, _, _, err := store.TakeN(ctx, "alice", 2, 5, time.Minute, limiter.SlidingWindow)
+	require.NoError(t, err)
+	_, _, _, err = store.TakeN(ctx, "shadow:strict:alice", 1, 1, time.Minute, limiter.FixedWindow)
+	require.NoError(t, err)
+	_, _, _, err = store.Take(ctx, "alice", 3, time.Minute, limiter.FixedWindow)
+	require.NoError(t, err)
+	require.NoError(t, store.Override(ctx, "bob", 10, time.Hour))
+	return mr, store
+}
+
+func TestInspect(t *testing.T) {
+	mr, _ := seed(t)
+	code, out, stderr := runCtl(t, "-redis", mr.Addr(), "-namespace", "shop", "inspect", "alice")
+	require.Equal(t, 0, code, stderr)
+	assert.Regexp(t, `fixed-window\s+rate_limit:shop:fixed-window:alice\s+count=3\s+1m0s`, out)
+	assert.Regexp(t, `token-bucket\s+rate_limit:shop:token-bucket:alice\s+-\s+-`, out)
+	assert.Regexp(t, `sliding-window\s+rate_limit:shop:sliding-window:alice\s+hits=2 oldest=`, out)
+	assert.Contains(t, out, "override: -")
+	assert.Contains(t, out, "denied:   1")
+
+	code, out, _ = runCtl(t, "-redis", "redis://"+mr.Addr()+"/0", "-namespace", "shop", "inspect", "bob")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, "override: 10 for 1h0m0s")
+}
+
+func TestReset(t *testing.T) {
+	mr, _ := seed(t)
+	code, out, _ := runCtl(t, "-redis", mr.Addr(), "-namespace", "shop", "reset", "-dry-run", "a*")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, "rate_limit:shop:fixed-window:alice\n")
+	assert.Contains(t, out, "2 keys match")
+	assert.True(t, mr.Exists("rate_limit:shop:fixed-window:alice"))
+
+	code, out, _ = runCtl(t, "-redis", mr.Addr(), "-namespace", "shop", "reset", "-algorithm", "fixed-window", "*")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, "deleted 4 keys")
+	assert.False(t, mr.Exists("rate_limit:shop:fixed-window:alice"))
+	assert.False(t, mr.Exists("rate_limit:shop:fixed-window:shadow:strict:alice"))
+	assert.True(t, mr.Exists("rate_limit:shop:sliding-window:alice"))
+	// The denials of alice are kept while one of its algorithms is.
+	members, err := mr.ZMembers("rate_limit:shop:limited")
+	require.NoError(t, err)
+	assert.Contains(t, members, "alice")
+
+	code, out, _ = runCtl(t, "-redis", mr.Addr(), "-namespace", "shop", "reset", "alice")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, "deleted 1 keys")
+	assert.False(t, mr.Exists("rate_limit:shop:sliding-window:alice"))
+	members, err = mr.ZMembers("rate_limit:shop:limited")
+	if err == nil {
+		assert.NotContains(t, members, "alice")
+	}
+}
+
+func TestTake(t *testing.T) {
+	mr, _ := seed(t)
+	args := []string{"-redis", mr.Addr(), "-namespace", "shop", "take", "-limit", "3", "-window", "1m", "-algorithm", "fixed-window"}
+
+	code, out, _ := runCtl(t, append(args, "carol")...)
+	require.Equal(t, 0, code)
+	assert.Regexp(t, `decision\s+allow \(simulated`, out)
+	assert.Regexp(t, `remaining\s+0`, out)
+	assert.Equal(t, "2", must(mr.Get("rate_limit:shop:fixed-window:carol")))
+
+	code, out, _ = runCtl(t, append(args, "-commit", "-n", "2", "carol")...)
+	require.Equal(t, 0, code)
+	assert.Regexp(t, `decision\s+partial`, out)
+	assert.Regexp(t, `granted\s+1 of 2`, out)
+	assert.Equal(t, "3", must(mr.Get("rate_limit:shop:fixed-window:carol")))
+
+	code, out, _ = runCtl(t, append(args, "bob")...)
+	require.Equal(t, 0, code)
+	assert.Regexp(t, `limit\s+10 \(override\)`, out)
+
+	code, _, stderr := runCtl(t, "-redis", mr.Addr(), "take", "carol")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "Usage: limiterctl take")
+}
+
+func TestTop(t *testing.T) {
+	mr, _ := seed(t)
+	code, out, _ := runCtl(t, "-redis", mr.Addr(), "-namespace", "shop", "top", "-n", "3")
+	require.Equal(t, 0, code)
+	assert.Regexp(t, `(?s)COUNT\s+ALGORITHM\s+KEY\n3\s+fixed-window\s+alice\n2\s+sliding-window\s+alice\n2\s+fixed-window\s+carol\n$`, out)
+
+	code, out, _ = runCtl(t, "-redis", mr.Addr(), "-namespace", "shop", "top", "-algorithm", "fixed-window", "-shadow")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, "shadow:strict:alice")
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "policy.yaml")
+	require.NoError(t, os.WriteFile(valid, []byte(`
+limiters:
+  api: {max_requests: 10, window: 1m, algorithm: sliding-window}
+rules:
+  - {route: /api/, limiter: api}
+`), 0o600))
+	code, out, _ := runCtl(t, "validate", "-no-env", valid)
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, "ok, 1 limiters, 1 rules")
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalid, []byte(`
+limiters:
+  api: {max_requests: 0, window: 1m, algorithm: leaky}
+`), 0o600))
+	code, out, stderr := runCtl(t, "validate", "-no-env", invalid)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out, "limiters.api.max_requests: must be positive\n")
+	assert.Contains(t, out, "limiters.api.algorithm: unknown algorithm")
+	assert.Contains(t, stderr, "2 invalid fields")
+}
+
+func TestUsage(t *testing.T) {
+	code, _, stderr := runCtl(t)
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "Commands:")
+
+	code, _, stderr = runCtl(t, "frobnicate")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unknown command "frobnicate"`)
+}
+
+func must(value string, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return value
+}
+
+func TestSimulate(t *testing.T) {
+	var log strings.Builder
+	for i := 0; i < 6; i++ {
+		fmt.Fprintf(&log, "203.0.113.7 - - [01/Jan/2024:00:00:%02d +0000] \"GET / HTTP/1.1\" 200 1\n", i)
+	}
+	log.WriteString("198.51.100.1 - - [01/Jan/2024:00:00:03 +0000] \"GET / HTTP/1.1\" 200 1\n")
+	path := filepath.Join(t.TempDir(), "access.log")
+	require.NoError(t, os.WriteFile(path, []byte(log.String()), 0o600))
+
+	code, out, stderr := runCtl(t, "simulate", "-limit", "5", "-window", "1m", "-algorithm", "fixed-window, sliding-window", path)
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, out, "7 requests from 2 keys")
+	assert.Regexp(t, `fixed-window\s+5/1m0s\s+7\s+1\s+14.29%\s+1\s+5`, out)
+	assert.Regexp(t, `sliding-window, most limited keys:\nKEY.*\n203.0.113.7\s+6\s+1\s+6\s+5\s+0s`, out)
+
+	code, _, stderr = runCtl(t, "simulate", "-limit", "5", "-window", "1m", "-algorithm", "leaky", path)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "leaky")
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// resetBatch is how many keys are deleted per round trip.
+const resetBatch = 500
+
+func (c *cli) reset(ctx context.Context, args []string) error {
+	fs := c.flags("reset", "<pattern>")
+	algorithm := fs.String("algorithm", "", "only reset keys of this algorithm, keeping their denial counts")
+	dryRun := fs.Bool("dry-run", false, "list the matching keys without deleting them")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	all, err := algorithms(*algorithm)
+	if err != nil {
+		return err
+	}
+	if err := c.connect(ctx); err != nil {
+		return err
+	}
+
+	// Denials are counted per key, not per algorithm: keep them unless
+	// every algorithm is reset.
+	forget := *algorithm == ""
+	total := 0
+	for _, a := range all {
+		prefix := c.store.Prefix() + string(a) + ":"
+		var keys, members []string
+		flush := func() error {
+			if len(keys) == 0 || *dryRun {
+				return nil
+			}
+			pipe := c.client.TxPipeline()
+			pipe.Unlink(ctx, keys...)
+			if forget {
+				pipe.ZRem(ctx, c.store.LimitedKey(), anySlice(members)...)
+			}
+			_, err := pipe.Exec(ctx)
+			keys, members = keys[:0], members[:0]
+			return err
+		}
+
+		iter := c.client.Scan(ctx, 0, escapeGlob(prefix)+fs.Arg(0), resetBatch).Iterator()
+		for iter.Next(ctx) {
+			key := iter.Val()
+			if *dryRun {
+				fmt.Fprintln(c.out, key)
+			}
+			keys = append(keys, key)
+			members = append(members, strings.TrimPrefix(key, prefix))
+			total++
+			if len(keys) >= resetBatch {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	if *dryRun {
+		fmt.Fprintf(c.out, "%d keys match\n", total)
+	} else {
+		fmt.Fprintf(c.out, "deleted %d keys\n", total)
+	}
+	return nil
+}
+
+// escapeGlob quotes the glob metacharacters of s for SCAN MATCH.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func anySlice(values []string) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/NarmadaWeb/limiter/v3/simulate"
+)
+
+func (c *cli) simulate(_ context.Context, args []string) error {
+	fs := c.flags("simulate", "<log file or ->")
+	limit := fs.Int("limit", 0, "MaxRequests to simulate")
+	window := fs.Duration("window", 0, "Window to simulate")
+	algorithmList := fs.String("algorithm", "token-bucket,sliding-window,fixed-window", "comma separated algorithms to compare")
+	format := fs.String("format", string(simulate.Common), "log format: common or json")
+	key := fs.String("key", "", "common: host, user, path or request; json: key field")
+	timeField := fs.String("time-field", "", "json: time field, timestamp by default")
+	skipInvalid := fs.Bool("skip-invalid", false, "skip lines that cannot be parsed")
+	top := fs.Int("top", 10, "most limited keys listed per algorithm")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	if *limit <= 0 || *window <= 0 {
+		fs.Usage()
+		return errUsage
+	}
+	var algorithms []limiter.Algorithm
+	for _, name := range strings.Split(*algorithmList, ",") {
+		a := limiter.Algorithm(strings.TrimSpace(name))
+		if !a.Valid() {
+			return fmt.Errorf("%w: %q", limiter.ErrInvalidAlgorithm, a)
+		}
+		algorithms = append(algorithms, a)
+	}
+
+	var in io.Reader = os.Stdin
+	if path := fs.Arg(0); path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+	}
+	events, err := simulate.Read(in, simulate.ReadOptions{
+		Format:      simulate.Format(*format),
+		Key:         *key,
+		TimeField:   *timeField,
+		SkipInvalid: *skipInvalid,
+	})
+	if err != nil {
+		return err
+	}
+
+	reports := make([]*simulate.Report, len(algorithms))
+	for i, a := range algorithms {
+		if reports[i], err = simulate.Run(events, limiter.Config{MaxRequests: *limit, Window: *window, Algorithm: a}); err != nil {
+			return err
+		}
+	}
+
+	if len(events) > 0 {
+		fmt.Fprintf(c.out, "%d requests from %d keys, %s to %s\n\n", len(events), len(reports[0].Keys),
+			reports[0].Start.Format(timeFormat), reports[0].End.Format(timeFormat))
+	}
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ALGORITHM\tLIMIT\tREQUESTS\tDENIED\tLIMITED\tKEYS LIMITED\tPEAK ADMITTED")
+	for _, r := range reports {
+		fmt.Fprintf(w, "%s\t%d/%s\t%d\t%d\t%.2f%%\t%d\t%d\n", r.Config.Algorithm, *limit, *window,
+			r.Requests, r.Denied, r.LimitedPercent(), r.LimitedKeys(), r.PeakAdmitted())
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, r := range reports {
+		if r.Denied == 0 || *top <= 0 {
+			continue
+		}
+		fmt.Fprintf(c.out, "\n%s, most limited keys:\n", r.Config.Algorithm)
+		w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tREQUESTS\tDENIED\tPEAK DEMAND\tPEAK ADMITTED\tLONGEST BLOCK")
+		for i, k := range r.Keys {
+			if i == *top || k.Denied == 0 {
+				break
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\n", k.Key, k.Requests, k.Denied, k.PeakDemand, k.PeakAdmitted, k.LongestBlock)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+)
+
+func (c *cli) take(ctx context.Context, args []string) error {
+	fs := c.flags("take", "<key>")
+	limit := fs.Int("limit", 0, "MaxRequests of the limiter")
+	window := fs.Duration("window", 0, "Window of the limiter")
+	algorithm := fs.String("algorithm", "", "Algorithm of the limiter")
+	n := fs.Int("n", 1, "requests to take")
+	commit := fs.Bool("commit", false, "take the requests instead of simulating")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	if *limit <= 0 || *window <= 0 || *algorithm == "" || *n <= 0 {
+		fs.Usage()
+		return errUsage
+	}
+	all, err := algorithms(*algorithm)
+	if err != nil {
+		return err
+	}
+	if err := c.connect(ctx); err != nil {
+		return err
+	}
+	key, a := fs.Arg(0), all[0]
+
+	status, err := c.store.Status(ctx, key, *limit, *window, a)
+	if err != nil {
+		return err
+	}
+	limitNote := ""
+	if status.Override > 0 {
+		limitNote = " (override)"
+	}
+
+	var (
+		decision  string
+		granted   int
+		remaining int
+		reset     time.Time
+	)
+	if *commit {
+		granted, remaining, reset, err = c.store.TakeN(ctx, key, *n, *limit, *window, a)
+		if err != nil {
+			return err
+		}
+		decision = decide(granted, *n)
+	} else {
+		granted = min(*n, status.Remaining)
+		remaining, reset = status.Remaining-granted, status.Reset
+		decision = decide(granted, *n) + " (simulated, run with -commit to take)"
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "key\t%s\n", key)
+	fmt.Fprintf(w, "algorithm\t%s\n", a)
+	fmt.Fprintf(w, "limit\t%d%s\n", status.Limit, limitNote)
+	fmt.Fprintf(w, "count\t%d\n", status.Count)
+	fmt.Fprintf(w, "decision\t%s\n", decision)
+	fmt.Fprintf(w, "granted\t%d of %d\n", granted, *n)
+	fmt.Fprintf(w, "remaining\t%d\n", remaining)
+	fmt.Fprintf(w, "reset\t%s\n", reset.UTC().Format(timeFormat))
+	return w.Flush()
+}
+
+func decide(granted, n int) string {
+	switch {
+	case granted == n:
+		return "allow"
+	case granted > 0:
+		return "partial"
+	default:
+		return "deny"
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/redis/go-redis/v9"
+)
+
+// topScan is the SCAN COUNT hint and the size of each pipelined read.
+const topScan = 500
+
+type consumer struct {
+	algorithm limiter.Algorithm
+	key       string
+	count     int
+}
+
+func (c *cli) top(ctx context.Context, args []string) error {
+	fs := c.flags("top", "")
+	algorithm := fs.String("algorithm", "", "only list keys of this algorithm")
+	n := fs.Int("n", 10, "number of keys to list")
+	shadow := fs.Bool("shadow", false, "include the keys of shadow limits")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	all, err := algorithms(*algorithm)
+	if err != nil {
+		return err
+	}
+	if err := c.connect(ctx); err != nil {
+		return err
+	}
+
+	var consumers []consumer
+	for _, a := range all {
+		prefix := c.store.Prefix() + string(a) + ":"
+		var keys []string
+		iter := c.client.Scan(ctx, 0, escapeGlob(prefix)+"*", topScan).Iterator()
+		for iter.Next(ctx) {
+			if key := iter.Val(); *shadow || !strings.HasPrefix(key, prefix+"shadow:") {
+				keys = append(keys, key)
+			}
+			if len(keys) >= topScan {
+				if consumers, err = c.counts(ctx, a, prefix, keys, consumers); err != nil {
+					return err
+				}
+				keys = keys[:0]
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+		if consumers, err = c.counts(ctx, a, prefix, keys, consumers); err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(consumers, func(i, j int) bool {
+		if consumers[i].count != consumers[j].count {
+			return consumers[i].count > consumers[j].count
+		}
+		return consumers[i].key < consumers[j].key
+	})
+	if len(consumers) > *n {
+		consumers = consumers[:*n]
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COUNT\tALGORITHM\tKEY")
+	for _, con := range consumers {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", con.count, con.algorithm, con.key)
+	}
+	return w.Flush()
+}
+
+// counts reads the requests counted in keys with one pipeline. Token
+// buckets are read as last written, without refilling.
+func (c *cli) counts(ctx context.Context, a limiter.Algorithm, prefix string, keys []string, consumers []consumer) ([]consumer, error) {
+	if len(keys) == 0 {
+		return consumers, nil
+	}
+	pipe := c.client.Pipeline()
+	cmds := make([]redis.Cmder, len(keys))
+	for i, key := range keys {
+		switch a {
+		case limiter.TokenBucket:
+			cmds[i] = pipe.HMGet(ctx, key, "tokens", "max")
+		case limiter.SlidingWindow:
+			cmds[i] = pipe.ZCard(ctx, key)
+		default:
+			cmds[i] = pipe.Get(ctx, key)
+		}
+	}
+	// Keys may expire between SCAN and the reads; their errors are
+	// checked one by one below.
+	_, _ = pipe.Exec(ctx)
+
+	for i, cmd := range cmds {
+		var count int
+		switch cmd := cmd.(type) {
+		case *redis.SliceCmd:
+			values, err := cmd.Result()
+			if err != nil || len(values) != 2 {
+				continue
+			}
+			tokens, _ := strconv.ParseFloat(fmt.Sprint(values[0]), 64)
+			maxRequests, _ := strconv.Atoi(fmt.Sprint(values[1]))
+			count = maxRequests - int(math.Floor(tokens))
+		case *redis.IntCmd:
+			value, err := cmd.Result()
+			if err != nil {
+				continue
+			}
+			count = int(value)
+		case *redis.StringCmd:
+			value, err := cmd.Int()
+			if err != nil {
+				continue
+			}
+			count = value
+		}
+		if count > 0 {
+			consumers = append(consumers, consumer{algorithm: a, key: strings.TrimPrefix(keys[i], prefix), count: count})
+		}
+	}
+	return consumers, nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NarmadaWeb/limiter/config/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+)
+
+func (c *cli) validate(_ context.Context, args []string) error {
+	fs := c.flags("validate", "<policy file>")
+	noEnv := fs.Bool("no-env", false, "ignore LIMITER_* environment overrides")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+
+	path := fs.Arg(0)
+	f, err := config.LoadWithOptions(path, config.Options{NoEnv: *noEnv})
+	var cfgErr *limiter.ConfigError
+	if errors.As(err, &cfgErr) {
+		for _, field := range cfgErr.Fields {
+			fmt.Fprintf(c.out, "%s: %s\n", field.Field, field.Message)
+		}
+		return fmt.Errorf("%s: %d invalid fields", path, len(cfgErr.Fields))
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "%s: ok, %d limiters, %d rules\n", path, len(f.Limiters), len(f.Rules))
+	return nil
+}
@@ -0,0 +1,254 @@
+// Package config builds limiters and route policies from a YAML, JSON or
+// TOML file, so limits can be managed as configuration:
+//
+//	store:
+//	  type: redis
+//	  redis:
+//	    url: redis://localhost:6379/0
+//	limiters:
+//	  api:
+//	    max_requests: 100
+//	    window: 1m
+//	    algorithm: sliding-window
+//	    failure_policy: fail-open
+//	  login:
+//	    max_requests: 5
+//	    window: 15m
+//	    algorithm: fixed-window
+//	rules:
+//	  - route: POST /login
+//	    limiter: login
+//	    key: ip
+//	  - route: /api/
+//	    limiter: api
+//	    key: header:X-Api-Key
+//	    headers: ietf
+//
+// Any value of the file can be overridden by an environment variable named
+// after its path, e.g. LIMITER_LIMITERS_API_MAX_REQUESTS=500 or
+// LIMITER_STORE_REDIS_URL.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultEnvPrefix prefixes the environment variables read by Load.
+const DefaultEnvPrefix = "LIMITER"
+
+// Format is the syntax of a configuration file.
+type Format string
+
+const (
+	YAML Format = "yaml"
+	JSON Format = "json"
+	TOML Format = "toml"
+)
+
+// File is the schema of a configuration file.
+type File struct {
+	Store Store `json:"store"`
+	// Limiters are referenced by name from Rules
+	Limiters map[string]Limiter `json:"limiters"`
+	// Rules are matched like http.ServeMux patterns, the most specific wins
+	Rules []Rule `json:"rules"`
+}
+
+// Store selects where the counters live.
+type Store struct {
+	// Type is "memory" (default) or "redis"
+	Type   string  `json:"type"`
+	Redis  *Redis  `json:"redis"`
+	Memory *Memory `json:"memory"`
+}
+
+// Redis configures the client shared by every limiter and their redis
+// stores.
+type Redis struct {
+	// URL is a redis:// URL or a host:port address
+	URL      string `json:"url"`
+	Password string `json:"password"`
+	DB       int    `json:"db"`
+
+	Prefix    string `json:"prefix"`
+	Namespace string `json:"namespace"`
+	// KeyHash is "none" (default), "sha1" or "xxhash"
+	KeyHash    string `json:"key_hash"`
+	ServerTime bool   `json:"server_time"`
+}
+
+// Memory configures the MemoryStore of each limiter.
+type Memory struct {
+	Shards   int `json:"shards"`
+	MaxKeys  int `json:"max_keys"`
+	MaxBytes int `json:"max_bytes"`
+	// Eviction is "lru" (default) or "most-remaining"
+	Eviction string `json:"eviction"`
+}
+
+// Limiter is one limit, as in limiter.Config.
+type Limiter struct {
+	MaxRequests int      `json:"max_requests"`
+	Window      Duration `json:"window"`
+	Algorithm   string   `json:"algorithm"`
+	// FailurePolicy is "fail-closed" (default), "fail-open" or
+	// "fallback-to-local"
+	FailurePolicy    string   `json:"failure_policy"`
+	FallbackScale    float64  `json:"fallback_scale"`
+	RecoveryInterval Duration `json:"recovery_interval"`
+	DryRun           bool     `json:"dry_run"`
+	Shadow           []Shadow `json:"shadow"`
+}
+
+// Shadow is a limiter.ShadowLimit.
+type Shadow struct {
+	Name        string   `json:"name"`
+	MaxRequests int      `json:"max_requests"`
+	Window      Duration `json:"window"`
+	Algorithm   string   `json:"algorithm"`
+}
+
+// Rule applies a limiter to the requests matching Route.
+type Rule struct {
+	// Route is an http.ServeMux pattern such as "/api/" or "POST /login"
+	Route   string `json:"route"`
+	Limiter string `json:"limiter"`
+	// Key names a key strategy, "ip" by default. See RegisterKeyStrategy.
+	Key string `json:"key"`
+	// Headers is "x-ratelimit" (default), "ietf" or "none"
+	Headers        string `json:"headers"`
+	SkipSuccessful bool   `json:"skip_successful"`
+}
+
+// Duration is a time.Duration written as "1m30s".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"1m\": %w", err)
+	}
+	return d.UnmarshalText([]byte(s))
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Options configures Load and Parse.
+type Options struct {
+	// Format overrides the format Load picks from the file extension
+	Format Format
+	// EnvPrefix defaults to DefaultEnvPrefix
+	EnvPrefix string
+	// Environ is the environment, defaults to os.Environ()
+	Environ []string
+	// NoEnv disables environment overrides
+	NoEnv bool
+}
+
+// Load reads the file at path, picking the format from its extension,
+// applies environment overrides and validates the result.
+func Load(path string) (*File, error) {
+	return LoadWithOptions(path, Options{})
+}
+
+// LoadWithOptions is Load with explicit Options.
+func LoadWithOptions(path string, opts Options) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			opts.Format = YAML
+		case ".json":
+			opts.Format = JSON
+		case ".toml":
+			opts.Format = TOML
+		default:
+			return nil, fmt.Errorf("%w: cannot tell the format of %s", limiter.ErrInvalidConfig, path)
+		}
+	}
+	f, err := Parse(data, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Parse decodes data, applies environment overrides and validates the
+// result. Unknown fields are an error. opts.Format defaults to YAML, a
+// superset of JSON.
+func Parse(data []byte, opts Options) (*File, error) {
+	// Every format is decoded to generic values first, so one set of json
+	// tags describes the schema.
+	var doc any
+	switch opts.Format {
+	case "", YAML:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("%w: %w", limiter.ErrInvalidConfig, err)
+		}
+	case JSON:
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("%w: %w", limiter.ErrInvalidConfig, err)
+		}
+	case TOML:
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("%w: %w", limiter.ErrInvalidConfig, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", limiter.ErrInvalidConfig, opts.Format)
+	}
+
+	f := &File{}
+	if doc != nil {
+		normalized, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", limiter.ErrInvalidConfig, err)
+		}
+		dec := json.NewDecoder(bytes.NewReader(normalized))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(f); err != nil {
+			return nil, fmt.Errorf("%w: %w", limiter.ErrInvalidConfig, err)
+		}
+	}
+
+	if !opts.NoEnv {
+		prefix, environ := opts.EnvPrefix, opts.Environ
+		if prefix == "" {
+			prefix = DefaultEnvPrefix
+		}
+		if environ == nil {
+			environ = os.Environ()
+		}
+		if err := applyEnv(f, prefix, environ); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
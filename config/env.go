@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/NarmadaWeb/limiter/v3"
+)
+
+var textUnmarshalerType = reflect.TypeFor[interface{ UnmarshalText([]byte) error }]()
+
+// applyEnv overrides every value of f that has a variable in environ. The
+// variable of a value is prefix followed by its path in the file, upper
+// cased and joined by underscores: store.redis.url is LIMITER_STORE_REDIS_URL
+// and the window of limiters.api is LIMITER_LIMITERS_API_WINDOW. Limiters
+// and rules must exist in the file to be overridden.
+func applyEnv(f *File, prefix string, environ []string) error {
+	vars := make(map[string]string)
+	for _, kv := range environ {
+		if name, value, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(name, prefix+"_") {
+			vars[name] = value
+		}
+	}
+	if len(vars) == 0 {
+		return nil
+	}
+
+	errs := &limiter.ConfigError{}
+	walkEnv(reflect.ValueOf(f).Elem(), []string{prefix}, nil, vars, errs)
+	if len(errs.Fields) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func walkEnv(v reflect.Value, env, path []string, vars map[string]string, errs *limiter.ConfigError) {
+	name := envName(env)
+	if value, ok := vars[name]; ok && isLeaf(v.Type()) {
+		if err := setFromEnv(v, value); err != nil {
+			errs.Fields = append(errs.Fields, limiter.FieldError{
+				Field:   strings.Join(path, "."),
+				Message: fmt.Sprintf("%s: %v", name, err),
+			})
+		}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !hasPrefix(vars, name+"_") {
+			return
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		walkEnv(v.Elem(), env, path, vars, errs)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			tag, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+			walkEnv(v.Field(i), slices.Concat(env, []string{tag}), slices.Concat(path, []string{tag}), vars, errs)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			walkEnv(elem, slices.Concat(env, []string{key}), slices.Concat(path, []string{key}), vars, errs)
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			index := strconv.Itoa(i)
+			walkEnv(v.Index(i), slices.Concat(env, []string{index}), slices.Concat(path, []string{index}), vars, errs)
+		}
+	}
+}
+
+func envName(env []string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(strings.Join(env, "_")))
+}
+
+func hasPrefix(vars map[string]string, prefix string) bool {
+	for name := range vars {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLeaf reports whether a value of t is set from a single variable.
+func isLeaf(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func setFromEnv(v reflect.Value, value string) error {
+	if u, ok := v.Addr().Interface().(interface{ UnmarshalText([]byte) error }); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+module github.com/NarmadaWeb/limiter/config/v3
+
+go 1.24.0
+
+require (
+	github.com/NarmadaWeb/limiter/redis/v3 v3.0.0
+	github.com/NarmadaWeb/limiter/v3 v3.0.0
+	github.com/pelletier/go-toml/v2 v2.2.4
+	github.com/redis/go-redis/v9 v9.17.2
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+)
@@ -0,0 +1,241 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	redisstore "github.com/NarmadaWeb/limiter/redis/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/redis/go-redis/v9"
+)
+
+// KeyFunc extracts the rate limit key of a request.
+type KeyFunc func(r *http.Request) string
+
+var (
+	keyStrategiesMu sync.RWMutex
+	keyStrategies   = map[string]KeyFunc{}
+)
+
+// RegisterKeyStrategy makes fn available to rules as key: name. The
+// built-in strategies are:
+//
+//   - ip: X-Forwarded-For, falling back to the remote address (default)
+//   - remote-addr: the remote address, ignoring proxy headers
+//   - header:<Name>: the value of a request header
+//   - query:<name>: the value of a query parameter
+//   - path: the request path
+//   - global: one key shared by every request
+func RegisterKeyStrategy(name string, fn KeyFunc) error {
+	if name == "" || fn == nil || strings.Contains(name, ":") {
+		return fmt.Errorf("%w: invalid key strategy %q", limiter.ErrInvalidConfig, name)
+	}
+	if _, err := builtinKeyStrategy(name); err == nil {
+		return fmt.Errorf("%w: key strategy %q is built in", limiter.ErrInvalidConfig, name)
+	}
+
+	keyStrategiesMu.Lock()
+	defer keyStrategiesMu.Unlock()
+	if _, ok := keyStrategies[name]; ok {
+		return fmt.Errorf("%w: key strategy %q is already registered", limiter.ErrInvalidConfig, name)
+	}
+	keyStrategies[name] = fn
+	return nil
+}
+
+// keyStrategy resolves name. A nil KeyFunc selects the middleware default.
+func keyStrategy(name string) (KeyFunc, error) {
+	if fn, err := builtinKeyStrategy(name); err == nil {
+		return fn, nil
+	}
+	keyStrategiesMu.RLock()
+	defer keyStrategiesMu.RUnlock()
+	if fn, ok := keyStrategies[name]; ok {
+		return fn, nil
+	}
+	return nil, fmt.Errorf("unknown key strategy %q", name)
+}
+
+func builtinKeyStrategy(name string) (KeyFunc, error) {
+	kind, arg, _ := strings.Cut(name, ":")
+	switch {
+	case name == "" || name == "ip":
+		return nil, nil
+	case name == "remote-addr":
+		return func(r *http.Request) string {
+			if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+				return host
+			}
+			return r.RemoteAddr
+		}, nil
+	case name == "path":
+		return func(r *http.Request) string { return r.URL.Path }, nil
+	case name == "global":
+		return func(*http.Request) string { return "global" }, nil
+	case kind == "header" && arg != "":
+		return func(r *http.Request) string { return r.Header.Get(arg) }, nil
+	case kind == "query" && arg != "":
+		return func(r *http.Request) string { return r.URL.Query().Get(arg) }, nil
+	default:
+		return nil, errors.New("not built in")
+	}
+}
+
+func keyHash(name string) (redisstore.KeyHash, error) {
+	switch name {
+	case "", "none":
+		return redisstore.KeyHashNone, nil
+	case "sha1":
+		return redisstore.KeyHashSHA1, nil
+	case "xxhash":
+		return redisstore.KeyHashXXHash, nil
+	default:
+		return 0, fmt.Errorf("unknown key hash %q", name)
+	}
+}
+
+func eviction(name string) (limiter.EvictionPolicy, error) {
+	switch name {
+	case "", "lru":
+		return limiter.EvictLRU, nil
+	case "most-remaining":
+		return limiter.EvictMostRemaining, nil
+	default:
+		return 0, fmt.Errorf("unknown eviction %q", name)
+	}
+}
+
+// Set holds the limiters built from a File and the rules routing requests
+// to them.
+type Set struct {
+	limiters map[string]*limiter.Limiter
+	rules    []Rule
+	client   *redis.Client
+}
+
+// New builds every limiter of f. Limiters with a redis store share one
+// client and keep their counters apart by name.
+func New(f *File) (*Set, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := &Set{limiters: make(map[string]*limiter.Limiter, len(f.Limiters)), rules: f.Rules}
+	if f.Store.Type == "redis" {
+		client, err := redisClient(f.Store.Redis)
+		if err != nil {
+			return nil, err
+		}
+		s.client = client
+	}
+
+	for name, l := range f.Limiters {
+		cfg := l.config(name)
+		if s.client != nil {
+			r := f.Store.Redis
+			hash, _ := keyHash(r.KeyHash)
+			cfg.Store = redisstore.NewStoreWithOptions(s.client, redisstore.StoreOptions{
+				Prefix:        r.Prefix,
+				Namespace:     r.Namespace,
+				Name:          name,
+				KeyHash:       hash,
+				UseServerTime: r.ServerTime,
+			})
+		} else if m := f.Store.Memory; m != nil {
+			policy, _ := eviction(m.Eviction)
+			cfg.Store = limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{
+				Shards:   m.Shards,
+				MaxKeys:  m.MaxKeys,
+				MaxBytes: m.MaxBytes,
+				Eviction: policy,
+			})
+		}
+
+		built, err := limiter.New(cfg)
+		if err != nil {
+			_ = s.Close()
+			return nil, fmt.Errorf("limiters.%s: %w", name, err)
+		}
+		s.limiters[name] = built
+	}
+	return s, nil
+}
+
+func redisClient(r *Redis) (*redis.Client, error) {
+	opts := &redis.Options{Addr: r.URL}
+	if strings.Contains(r.URL, "://") {
+		var err error
+		if opts, err = redis.ParseURL(r.URL); err != nil {
+			return nil, fmt.Errorf("%w: store.redis.url: %w", limiter.ErrInvalidConfig, err)
+		}
+	}
+	if r.Password != "" {
+		opts.Password = r.Password
+	}
+	if r.DB != 0 {
+		opts.DB = r.DB
+	}
+	return redis.NewClient(opts), nil
+}
+
+// Limiter returns the limiter called name, or nil.
+func (s *Set) Limiter(name string) *limiter.Limiter {
+	return s.limiters[name]
+}
+
+// Middleware returns a net/http middleware applying the rule whose route
+// matches each request, like http.ServeMux would pick it. Requests
+// matching no rule are not limited.
+func (s *Set) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		mux := http.NewServeMux()
+		handlers := make([]http.Handler, len(s.rules))
+		for i, r := range s.rules {
+			keyFunc, _ := keyStrategy(r.Key)
+			headers, _ := limiter.ParseHeaderMode(r.Headers)
+			handlers[i] = s.limiters[r.Limiter].StdLibMiddleware(limiter.StdLibConfig{
+				KeyGenerator:    keyFunc,
+				Headers:         headers,
+				Skipsuccessfull: r.SkipSuccessful,
+			})(next)
+			mux.Handle(r.Route, ruleIndex(i))
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The mux only picks the rule; its redirects and 405s are not ours.
+			if h, _ := mux.Handler(r); h != nil {
+				if i, ok := h.(ruleIndex); ok {
+					handlers[i].ServeHTTP(w, r)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ruleIndex marks the rule a route belongs to in the mux.
+type ruleIndex int
+
+func (ruleIndex) ServeHTTP(http.ResponseWriter, *http.Request) {}
+
+// Close closes every limiter and the shared Redis client.
+func (s *Set) Close() error {
+	var errs []error
+	for _, l := range s.limiters {
+		// The limiters share the client, only the first close succeeds.
+		if err := l.Close(); err != nil && !errors.Is(err, redis.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	if s.client != nil {
+		if err := s.client.Close(); err != nil && !errors.Is(err, redis.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
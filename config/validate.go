@@ -0,0 +1,182 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NarmadaWeb/limiter/v3"
+)
+
+// fieldErrors collects schema errors by path in the file.
+type fieldErrors struct {
+	limiter.ConfigError
+}
+
+func (e *fieldErrors) add(field, format string, args ...any) {
+	e.Fields = append(e.Fields, limiter.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e *fieldErrors) addErr(field string, err error, format string, args ...any) {
+	e.Fields = append(e.Fields, limiter.FieldError{Field: field, Message: fmt.Sprintf(format, args...), Err: err})
+}
+
+// Validate checks f against the schema and reports every invalid value as
+// a *limiter.ConfigError whose fields are paths in the file, such as
+// "limiters.api.window".
+func (f *File) Validate() error {
+	errs := &fieldErrors{}
+
+	switch f.Store.Type {
+	case "", "memory":
+	case "redis":
+		if f.Store.Redis == nil || f.Store.Redis.URL == "" {
+			errs.add("store.redis.url", "is required for a redis store")
+		}
+	default:
+		errs.add("store.type", "unknown store %q, want memory or redis", f.Store.Type)
+	}
+	if r := f.Store.Redis; r != nil {
+		if _, err := keyHash(r.KeyHash); err != nil {
+			errs.add("store.redis.key_hash", "unknown key hash %q, want none, sha1 or xxhash", r.KeyHash)
+		}
+	}
+	if m := f.Store.Memory; m != nil {
+		if _, err := eviction(m.Eviction); err != nil {
+			errs.add("store.memory.eviction", "unknown eviction %q, want lru or most-remaining", m.Eviction)
+		}
+		if m.Shards < 0 || m.MaxKeys < 0 || m.MaxBytes < 0 {
+			errs.add("store.memory", "shards, max_keys and max_bytes must not be negative")
+		}
+	}
+
+	if len(f.Limiters) == 0 {
+		errs.add("limiters", "at least one limiter is required")
+	}
+	names := make([]string, 0, len(f.Limiters))
+	for name := range f.Limiters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f.Limiters[name].validate("limiters."+name, errs)
+	}
+
+	mux := http.NewServeMux()
+	for i, r := range f.Rules {
+		field := fmt.Sprintf("rules.%d", i)
+		if r.Route == "" {
+			errs.add(field+".route", "is required")
+		} else if err := register(mux, r.Route); err != nil {
+			errs.add(field+".route", "%v", err)
+		}
+		if _, ok := f.Limiters[r.Limiter]; !ok {
+			errs.add(field+".limiter", "unknown limiter %q", r.Limiter)
+		}
+		if _, err := keyStrategy(r.Key); err != nil {
+			errs.add(field+".key", "%v", err)
+		}
+		if r.Headers != "" {
+			if _, err := limiter.ParseHeaderMode(r.Headers); err != nil {
+				errs.add(field+".headers", "unknown header mode %q, want x-ratelimit, ietf or none", r.Headers)
+			}
+		}
+	}
+
+	if len(errs.Fields) > 0 {
+		return &errs.ConfigError
+	}
+	return nil
+}
+
+func (l Limiter) validate(field string, errs *fieldErrors) {
+	if l.MaxRequests <= 0 {
+		errs.add(field+".max_requests", "must be positive")
+	}
+	if l.Window <= 0 {
+		errs.add(field+".window", "must be positive")
+	}
+	if !limiter.Algorithm(l.Algorithm).Valid() {
+		errs.addErr(field+".algorithm", limiter.ErrInvalidAlgorithm, "unknown algorithm %q, want %s", l.Algorithm, validAlgorithms())
+	}
+	if l.FailurePolicy != "" {
+		if _, err := limiter.ParseFailurePolicy(l.FailurePolicy); err != nil {
+			errs.add(field+".failure_policy", "unknown policy %q, want fail-closed, fail-open or fallback-to-local", l.FailurePolicy)
+		}
+	}
+	if l.FallbackScale < 0 {
+		errs.add(field+".fallback_scale", "must not be negative")
+	}
+	if l.RecoveryInterval < 0 {
+		errs.add(field+".recovery_interval", "must not be negative")
+	}
+
+	seen := make(map[string]bool, len(l.Shadow))
+	for i, s := range l.Shadow {
+		shadow := fmt.Sprintf("%s.shadow.%d", field, i)
+		switch {
+		case s.Name == "":
+			errs.add(shadow+".name", "is required")
+		case seen[s.Name]:
+			errs.add(shadow+".name", "duplicate shadow %q", s.Name)
+		}
+		seen[s.Name] = true
+		if s.MaxRequests <= 0 {
+			errs.add(shadow+".max_requests", "must be positive")
+		}
+		if s.Window <= 0 {
+			errs.add(shadow+".window", "must be positive")
+		}
+		if s.Algorithm != "" && !limiter.Algorithm(s.Algorithm).Valid() {
+			errs.addErr(shadow+".algorithm", limiter.ErrInvalidAlgorithm, "unknown algorithm %q, want %s", s.Algorithm, validAlgorithms())
+		}
+	}
+}
+
+// register adds pattern to mux, turning its panics on invalid or
+// conflicting patterns into errors.
+func register(mux *http.ServeMux, pattern string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	mux.Handle(pattern, http.NotFoundHandler())
+	return nil
+}
+
+// config converts l to a limiter.Config without the store settings.
+func (l Limiter) config(name string) limiter.Config {
+	cfg := limiter.Config{
+		Name:             name,
+		MaxRequests:      l.MaxRequests,
+		Window:           time.Duration(l.Window),
+		Algorithm:        limiter.Algorithm(l.Algorithm),
+		FallbackScale:    l.FallbackScale,
+		RecoveryInterval: time.Duration(l.RecoveryInterval),
+		DryRun:           l.DryRun,
+	}
+	if l.FailurePolicy != "" {
+		cfg.FailurePolicy, _ = limiter.ParseFailurePolicy(l.FailurePolicy)
+	}
+	for _, s := range l.Shadow {
+		cfg.Shadow = append(cfg.Shadow, limiter.ShadowLimit{
+			Name:        s.Name,
+			MaxRequests: s.MaxRequests,
+			Window:      time.Duration(s.Window),
+			Algorithm:   limiter.Algorithm(s.Algorithm),
+		})
+	}
+	return cfg
+}
+
+// validAlgorithms lists the built-in algorithms and registered strategies.
+func validAlgorithms() string {
+	var valid []string
+	for _, a := range limiter.Algorithms() {
+		valid = append(valid, string(a))
+	}
+	return strings.Join(valid, ", ")
+}
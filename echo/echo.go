@@ -1,22 +1,25 @@
-package limiter
+// Package echo adapts the limiter to Echo. It is a module of its own so
+// that only its users depend on Echo.
+package echo
 
 import (
-	"fmt"
 	"net/http"
-	"strconv"
-	"time"
 
+	"github.com/NarmadaWeb/limiter/v3"
 	"github.com/labstack/echo/v4"
 )
 
-type EchoConfig struct {
+type Config struct {
 	KeyGenerator        func(c echo.Context) string
 	LimitReachedHandler func(c echo.Context) error
 	ErrorHandler        func(c echo.Context, err error) error
 	Skipsuccessfull     bool
+	// Headers selects the rate limit headers, HeadersXRateLimit by default
+	Headers limiter.HeaderMode
 }
 
-func (l *Limiter) EchoMiddleware(cfg EchoConfig) echo.MiddlewareFunc {
+// New creates an Echo middleware counting requests against l.
+func New(l *limiter.Limiter, cfg Config) echo.MiddlewareFunc {
 	// Set defaults
 	if cfg.KeyGenerator == nil {
 		cfg.KeyGenerator = func(c echo.Context) string {
@@ -44,24 +47,21 @@ func (l *Limiter) EchoMiddleware(cfg EchoConfig) echo.MiddlewareFunc {
 		return func(c echo.Context) error {
 			key := cfg.KeyGenerator(c)
 
-			allowed, remaining, reset, err := l.store.Take(l.ctx, key, l.config.MaxRequests, l.config.Window, l.config.Algorithm)
-			if err != nil {
+			o := l.Take(c.Request().Context(), key, func() limiter.RequestInfo { return limiter.HTTPRequestInfo(c.Request()) })
+			if err := o.Err(); err != nil {
 				return cfg.ErrorHandler(c, err)
 			}
 
-			c.Response().Header().Set("X-RateLimit-Limit", strconv.Itoa(l.config.MaxRequests))
-			c.Response().Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
-			c.Response().Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
-			c.Response().Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", l.config.MaxRequests, int(time.Minute.Seconds())))
+			o.SetHeaders(c.Response().Header().Set, cfg.Headers)
 
-			if !allowed {
+			if o.Rejected() {
 				return cfg.LimitReachedHandler(c)
 			}
 
-			err = next(c)
+			err := next(c)
 
 			if cfg.Skipsuccessfull && err == nil && c.Response().Status < http.StatusBadRequest {
-				_ = l.store.Rollback(l.ctx, key)
+				_ = o.Rollback(c.Request().Context())
 			}
 
 			return err
@@ -2,6 +2,9 @@ package limiter
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 )
 
 var (
@@ -9,4 +12,77 @@ var (
 	ErrStorage          = errors.New("storage error")
 	ErrRedisConnection  = errors.New("redis connection error")
 	ErrInvalidConfig    = errors.New("invalid configuration")
+	ErrLimitExceeded    = errors.New("rate limit exceeded")
+	// ErrCircuitOpen is a storage error, so errors.Is(err, ErrStorage) holds.
+	ErrCircuitOpen = fmt.Errorf("%w: circuit breaker is open", ErrStorage)
 )
+
+// FieldError describes one invalid Config field.
+type FieldError struct {
+	Field   string
+	Message string
+	// Err is the sentinel the field error matches, if any.
+	Err error
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+func (e FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigError lists every invalid field of a Config. It matches
+// ErrInvalidConfig and the sentinels of its fields with errors.Is.
+type ConfigError struct {
+	Fields []FieldError
+}
+
+func (e *ConfigError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return ErrInvalidConfig.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *ConfigError) Unwrap() []error {
+	errs := []error{ErrInvalidConfig}
+	for _, f := range e.Fields {
+		errs = append(errs, f)
+	}
+	return errs
+}
+
+// add records an invalid field.
+func (e *ConfigError) add(field, message string, err error) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message, Err: err})
+}
+
+// orNil returns e when it holds any field, nil otherwise.
+func (e *ConfigError) orNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// LimitExceededError is returned by Limiter.Allow when a key is over its
+// limit. It matches ErrLimitExceeded with errors.Is.
+type LimitExceededError struct {
+	Key       string
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	// RetryAfter is how long until the next request may be admitted.
+	RetryAfter time.Duration
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s: retry after %s", ErrLimitExceeded, e.RetryAfter)
+}
+
+func (e *LimitExceededError) Unwrap() error {
+	return ErrLimitExceeded
+}
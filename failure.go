@@ -0,0 +1,105 @@
+package limiter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FailurePolicy decides what happens to a request when the store fails.
+type FailurePolicy int
+
+const (
+	// FailClosed hands store errors to the adapter's ErrorHandler (default).
+	FailClosed FailurePolicy = iota
+	// FailOpen lets requests through while the store is failing.
+	FailOpen
+	// FallbackToLocal counts requests in an in-process MemoryStore, with a
+	// limit scaled by Config.FallbackScale, until the store recovers.
+	FallbackToLocal
+)
+
+func (p FailurePolicy) String() string {
+	switch p {
+	case FailClosed:
+		return "fail-closed"
+	case FailOpen:
+		return "fail-open"
+	case FallbackToLocal:
+		return "fallback-to-local"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseFailurePolicy parses the names returned by FailurePolicy.String.
+func ParseFailurePolicy(s string) (FailurePolicy, error) {
+	for _, p := range []FailurePolicy{FailClosed, FailOpen, FallbackToLocal} {
+		if p.String() == s {
+			return p, nil
+		}
+	}
+	return FailClosed, fmt.Errorf("%w: unknown failure policy %q", ErrInvalidConfig, s)
+}
+
+const defaultRecoveryInterval = 5 * time.Second
+
+// storeHealth tracks whether the backing store is reachable and reports
+// transitions to Config.OnStoreHealthChange.
+type storeHealth struct {
+	mu       sync.Mutex
+	healthy  bool
+	retryAt  time.Time
+	interval time.Duration
+	notify   func(healthy bool, err error)
+}
+
+func newStoreHealth(interval time.Duration, notify func(healthy bool, err error)) *storeHealth {
+	if interval <= 0 {
+		interval = defaultRecoveryInterval
+	}
+	return &storeHealth{
+		healthy:  true,
+		interval: interval,
+		notify:   notify,
+	}
+}
+
+// degraded reports whether the store is known to be down and should not be
+// retried yet.
+func (h *storeHealth) degraded(now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return !h.healthy && now.Before(h.retryAt)
+}
+
+func (h *storeHealth) fail(now time.Time, err error) {
+	h.mu.Lock()
+	changed := h.healthy
+	h.healthy = false
+	h.retryAt = now.Add(h.interval)
+	h.mu.Unlock()
+
+	if changed && h.notify != nil {
+		h.notify(false, err)
+	}
+}
+
+func (h *storeHealth) recover() {
+	h.mu.Lock()
+	changed := !h.healthy
+	h.healthy = true
+	h.mu.Unlock()
+
+	if changed && h.notify != nil {
+		h.notify(true, nil)
+	}
+}
+
+func (h *storeHealth) isHealthy() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.healthy
+}
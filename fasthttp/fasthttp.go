@@ -0,0 +1,90 @@
+// Package fasthttp adapts the limiter to plain fasthttp request handlers.
+// It is a module of its own so that only its users depend on fasthttp.
+package fasthttp
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/valyala/fasthttp"
+)
+
+type Config struct {
+	KeyGenerator        func(ctx *fasthttp.RequestCtx) string
+	LimitReachedHandler fasthttp.RequestHandler
+	ErrorHandler        func(ctx *fasthttp.RequestCtx, err error)
+	Skipsuccessfull     bool
+	// Headers selects the rate limit headers, HeadersXRateLimit by default
+	Headers limiter.HeaderMode
+}
+
+// New creates a fasthttp middleware counting requests against l.
+func New(l *limiter.Limiter, cfg Config) func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	// Set defaults
+	if cfg.KeyGenerator == nil {
+		cfg.KeyGenerator = func(ctx *fasthttp.RequestCtx) string {
+			return ctx.RemoteIP().String()
+		}
+	}
+	if cfg.LimitReachedHandler == nil {
+		cfg.LimitReachedHandler = func(ctx *fasthttp.RequestCtx) {
+			writeJSON(ctx, fasthttp.StatusTooManyRequests, map[string]string{
+				"error":   "rate limit exceeded",
+				"message": "Too many requests, please try again later",
+			})
+		}
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = func(ctx *fasthttp.RequestCtx, err error) {
+			writeJSON(ctx, fasthttp.StatusInternalServerError, map[string]string{
+				"error":   "rate limit error",
+				"message": err.Error(),
+			})
+		}
+	}
+
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			// Stores keep the key past the request, when the buffer a zero-copy
+			// key points into is reused.
+			key := strings.Clone(cfg.KeyGenerator(ctx))
+
+			o := l.Take(ctx, key, func() limiter.RequestInfo { return requestInfo(ctx) })
+			if err := o.Err(); err != nil {
+				cfg.ErrorHandler(ctx, err)
+				return
+			}
+
+			o.SetHeaders(ctx.Response.Header.Set, cfg.Headers)
+
+			if o.Rejected() {
+				cfg.LimitReachedHandler(ctx)
+				return
+			}
+
+			next(ctx)
+
+			if cfg.Skipsuccessfull && ctx.Response.StatusCode() < fasthttp.StatusBadRequest {
+				_ = o.Rollback(ctx)
+			}
+		}
+	}
+}
+
+func writeJSON(ctx *fasthttp.RequestCtx, code int, body map[string]string) {
+	ctx.SetStatusCode(code)
+	ctx.SetContentType("application/json")
+	_ = json.NewEncoder(ctx).Encode(body)
+}
+
+// requestInfo copies the request metadata; fasthttp reuses the buffers
+// behind its byte slices once the handler returns.
+func requestInfo(ctx *fasthttp.RequestCtx) limiter.RequestInfo {
+	return limiter.RequestInfo{
+		Method:     string(ctx.Method()),
+		Path:       string(ctx.Path()),
+		RemoteAddr: ctx.RemoteAddr().String(),
+		UserAgent:  string(ctx.UserAgent()),
+	}
+}
@@ -0,0 +1,86 @@
+package fasthttp_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+	"unsafe"
+
+	limiterfasthttp "github.com/NarmadaWeb/limiter/fasthttp/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/NarmadaWeb/limiter/v3/limitertest"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func TestAdapterConformance(t *testing.T) {
+	limitertest.RunAdapterConformance(t, func(t *testing.T, l *limiter.Limiter, cfg limitertest.AdapterConfig) http.RoundTripper {
+		fastCfg := limiterfasthttp.Config{Skipsuccessfull: cfg.Skipsuccessfull, Headers: cfg.Headers}
+		if cfg.KeyHeader != "" {
+			// Zero-copy, the way fasthttp handlers usually turn headers
+			// into strings.
+			fastCfg.KeyGenerator = func(ctx *fasthttp.RequestCtx) string {
+				b := ctx.Request.Header.Peek(cfg.KeyHeader)
+				return unsafe.String(unsafe.SliceData(b), len(b))
+			}
+		}
+		if cfg.CustomHandlers {
+			fastCfg.LimitReachedHandler = func(ctx *fasthttp.RequestCtx) {
+				ctx.SetStatusCode(limitertest.StatusLimitReached)
+			}
+			fastCfg.ErrorHandler = func(ctx *fasthttp.RequestCtx, _ error) {
+				ctx.SetStatusCode(limitertest.StatusError)
+			}
+		}
+
+		handler := limiterfasthttp.New(l, fastCfg)(func(ctx *fasthttp.RequestCtx) {
+			if string(ctx.Path()) == "/fail" {
+				ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+			}
+		})
+
+		ln := fasthttputil.NewInmemoryListener()
+		go func() { _ = fasthttp.Serve(ln, handler) }()
+		t.Cleanup(func() { _ = ln.Close() })
+
+		tr := &http.Transport{
+			DialContext: func(context.Context, string, string) (net.Conn, error) {
+				return ln.Dial()
+			},
+		}
+		t.Cleanup(tr.CloseIdleConnections)
+		return tr
+	})
+}
+
+func TestKeyOutlivesRequestBuffer(t *testing.T) {
+	l, err := limiter.New(limiter.Config{MaxRequests: 1, Window: time.Minute, Algorithm: limiter.FixedWindow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = l.Close() }()
+
+	// A zero-copy key points into a buffer that is overwritten once the
+	// request is done.
+	var buf []byte
+	handler := limiterfasthttp.New(l, limiterfasthttp.Config{
+		KeyGenerator: func(ctx *fasthttp.RequestCtx) string {
+			buf = append([]byte(nil), ctx.Request.Header.Peek("X-Api-Key")...)
+			return unsafe.String(unsafe.SliceData(buf), len(buf))
+		},
+	})(func(*fasthttp.RequestCtx) {})
+
+	for i, want := range []int{fasthttp.StatusOK, fasthttp.StatusTooManyRequests} {
+		var req fasthttp.Request
+		req.Header.Set("X-Api-Key", "client-a")
+		var ctx fasthttp.RequestCtx
+		ctx.Init(&req, nil, nil)
+		handler(&ctx)
+		copy(buf, "xxxxxxxx")
+		if got := ctx.Response.StatusCode(); got != want {
+			t.Fatalf("request %d: status %d, want %d", i+1, got, want)
+		}
+	}
+}
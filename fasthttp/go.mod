@@ -0,0 +1,14 @@
+module github.com/NarmadaWeb/limiter/fasthttp/v3
+
+go 1.24.0
+
+require (
+	github.com/NarmadaWeb/limiter/v3 v3.0.0
+	github.com/valyala/fasthttp v1.69.0
+)
+
+require (
+	github.com/andybalholm/brotli v1.2.0 // indirect
+	github.com/klauspost/compress v1.18.2 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+)
@@ -0,0 +1,22 @@
+module github.com/NarmadaWeb/limiter/fiber/v3
+
+go 1.24.0
+
+require (
+	github.com/NarmadaWeb/limiter/v3 v3.0.0
+	github.com/gofiber/fiber/v2 v2.52.9
+)
+
+require (
+	github.com/andybalholm/brotli v1.2.0 // indirect
+	github.com/clipperhouse/stringish v0.1.1 // indirect
+	github.com/clipperhouse/uax29/v2 v2.3.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/klauspost/compress v1.18.2 // indirect
+	github.com/mattn/go-colorable v0.1.14 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/mattn/go-runewidth v0.0.19 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasthttp v1.68.0 // indirect
+	golang.org/x/sys v0.38.0 // indirect
+)
@@ -0,0 +1,89 @@
+// Package fiberv3 adapts the limiter to Fiber v3. It is a module of its
+// own so that only its users depend on Fiber v3.
+package fiberv3
+
+import (
+	"strings"
+
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/gofiber/fiber/v3"
+)
+
+// Config is the Fiber v3 counterpart of fiber.Config in
+// github.com/NarmadaWeb/limiter/fiber/v3.
+type Config struct {
+	KeyGenerator        func(c fiber.Ctx) string
+	LimitReachedHandler fiber.Handler
+	ErrorHandler        func(c fiber.Ctx, err error) error
+	Skipsuccessfull     bool
+	// Headers selects the rate limit headers, HeadersXRateLimit by default
+	Headers limiter.HeaderMode
+}
+
+// New creates a Fiber v3 middleware counting requests against l.
+func New(l *limiter.Limiter, cfg Config) fiber.Handler {
+	// Set defaults
+	if cfg.KeyGenerator == nil {
+		cfg.KeyGenerator = defaultKeyGenerator
+	}
+	if cfg.LimitReachedHandler == nil {
+		cfg.LimitReachedHandler = defaultLimitReachedHandler
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = defaultErrorHandler
+	}
+
+	return func(c fiber.Ctx) error {
+		// Stores keep the key past the request, when the buffer a zero-copy
+		// key points into is reused.
+		key := strings.Clone(cfg.KeyGenerator(c))
+
+		o := l.Take(c.Context(), key, func() limiter.RequestInfo { return requestInfo(c) })
+		if err := o.Err(); err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		o.SetHeaders(c.Set, cfg.Headers)
+
+		if o.Rejected() {
+			return cfg.LimitReachedHandler(c)
+		}
+
+		err := c.Next()
+
+		if cfg.Skipsuccessfull && err == nil && c.Response().StatusCode() < fiber.StatusBadRequest {
+			return o.Rollback(c.Context())
+		}
+
+		return err
+	}
+}
+
+func defaultKeyGenerator(c fiber.Ctx) string {
+	return c.IP()
+}
+
+func defaultLimitReachedHandler(c fiber.Ctx) error {
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":   "rate limit exceeded",
+		"message": "Too many requests, please try again later",
+	})
+}
+
+func defaultErrorHandler(c fiber.Ctx, err error) error {
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error":   "rate limit error",
+		"message": err.Error(),
+	})
+}
+
+// requestInfo copies the request metadata; fiber reuses the buffers
+// behind its strings once the handler returns.
+func requestInfo(c fiber.Ctx) limiter.RequestInfo {
+	return limiter.RequestInfo{
+		Method:     strings.Clone(c.Method()),
+		Path:       strings.Clone(c.Path()),
+		RemoteAddr: c.RequestCtx().RemoteAddr().String(),
+		UserAgent:  strings.Clone(c.Get(fiber.HeaderUserAgent)),
+	}
+}
@@ -0,0 +1,206 @@
+package fiberv3_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/NarmadaWeb/limiter/fiberv3/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/NarmadaWeb/limiter/v3/limitertest"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndToEnd(t *testing.T) {
+	app := fiber.New()
+
+	clock := limiter.NewFakeClock(time.Now())
+	limiterCfg := limiter.Config{
+		MaxRequests: 2,
+		Window:      1 * time.Second,
+		Algorithm:   "fixed-window",
+		Clock:       clock,
+	}
+	l, err := limiter.New(limiterCfg)
+	assert.NoError(t, err)
+
+	app.Use(fiberv3.New(l, fiberv3.Config{}))
+
+	app.Get("/", func(c fiber.Ctx) error {
+		return c.SendString("OK")
+	})
+
+	// Test helper function
+	makeRequest := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	// First two requests should succeed
+	assert.Equal(t, http.StatusOK, makeRequest())
+	assert.Equal(t, http.StatusOK, makeRequest())
+
+	// Third request should fail
+	assert.Equal(t, http.StatusTooManyRequests, makeRequest())
+
+	// Move past the window reset
+	clock.Advance(1100 * time.Millisecond) // Slightly more than the window
+
+	// After reset, next request should succeed
+	assert.Equal(t, http.StatusOK, makeRequest())
+}
+
+func TestMiddlewareHeaders(t *testing.T) {
+	l, err := limiter.New(limiter.Config{MaxRequests: 1, Window: time.Minute, Algorithm: limiter.FixedWindow})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	app := fiber.New()
+	app.Use(fiberv3.New(l, fiberv3.Config{
+		KeyGenerator: func(c fiber.Ctx) string { return c.Get("X-Api-Key") },
+		Headers:      limiter.HeadersIETF,
+	}))
+	app.Get("/", func(c fiber.Ctx) error { return c.SendString("OK") })
+
+	request := func(key string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Api-Key", key)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := request("a")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("RateLimit-Limit"))
+	assert.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+	assert.Empty(t, resp.Header.Get("X-RateLimit-Limit"))
+
+	resp = request("a")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "60", resp.Header.Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, request("b").StatusCode)
+}
+
+func TestMiddlewareKeyOutlivesRequestBuffer(t *testing.T) {
+	l, err := limiter.New(limiter.Config{MaxRequests: 1, Window: time.Minute, Algorithm: limiter.FixedWindow})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	// Like c.Get, the key points into a buffer that is overwritten once the
+	// request is done.
+	var buf []byte
+	app := fiber.New()
+	app.Use(fiberv3.New(l, fiberv3.Config{
+		KeyGenerator: func(c fiber.Ctx) string {
+			buf = []byte(c.Get("X-Api-Key"))
+			return unsafe.String(unsafe.SliceData(buf), len(buf))
+		},
+	}))
+	app.Get("/", func(c fiber.Ctx) error { return c.SendString("OK") })
+
+	request := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Api-Key", "client-a")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		copy(buf, "xxxxxxxx")
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, request())
+	assert.Equal(t, http.StatusTooManyRequests, request())
+}
+
+func TestMiddlewareSkipSuccessful(t *testing.T) {
+	l, err := limiter.New(limiter.Config{MaxRequests: 1, Window: time.Minute, Algorithm: limiter.FixedWindow})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	app := fiber.New()
+	app.Use(fiberv3.New(l, fiberv3.Config{Skipsuccessfull: true}))
+	app.Get("/ok", func(c fiber.Ctx) error { return c.SendString("OK") })
+	app.Get("/fail", func(c fiber.Ctx) error { return c.SendStatus(http.StatusBadRequest) })
+
+	status := func(path string) int {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, status("/ok"))
+	}
+	assert.Equal(t, http.StatusBadRequest, status("/fail"))
+	assert.Equal(t, http.StatusTooManyRequests, status("/ok"))
+}
+
+// failingStore fails every call.
+type failingStore struct{ limiter.Store }
+
+func (failingStore) Take(context.Context, string, int, time.Duration, limiter.Algorithm) (bool, int, time.Time, error) {
+	return false, 0, time.Time{}, errors.New("store down")
+}
+
+func TestMiddlewareErrorHandler(t *testing.T) {
+	l, err := limiter.New(limiter.Config{
+		MaxRequests: 1,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+		Store:       failingStore{},
+	})
+	require.NoError(t, err)
+
+	app := fiber.New()
+	app.Use(fiberv3.New(l, fiberv3.Config{}))
+	app.Get("/", func(c fiber.Ctx) error { return c.SendString("OK") })
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	app = fiber.New()
+	app.Use(fiberv3.New(l, fiberv3.Config{
+		ErrorHandler: func(c fiber.Ctx, _ error) error { return c.SendStatus(http.StatusServiceUnavailable) },
+		LimitReachedHandler: func(c fiber.Ctx) error {
+			t.Fatal("no request is rejected when the store fails")
+			return nil
+		},
+	}))
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestAdapterConformance(t *testing.T) {
+	limitertest.RunAdapterConformance(t, func(t *testing.T, l *limiter.Limiter, cfg limitertest.AdapterConfig) http.RoundTripper {
+		fiberCfg := fiberv3.Config{Skipsuccessfull: cfg.Skipsuccessfull, Headers: cfg.Headers}
+		if cfg.KeyHeader != "" {
+			fiberCfg.KeyGenerator = func(c fiber.Ctx) string { return c.Get(cfg.KeyHeader) }
+		}
+		if cfg.CustomHandlers {
+			fiberCfg.LimitReachedHandler = func(c fiber.Ctx) error {
+				return c.SendStatus(limitertest.StatusLimitReached)
+			}
+			fiberCfg.ErrorHandler = func(c fiber.Ctx, _ error) error {
+				return c.SendStatus(limitertest.StatusError)
+			}
+		}
+
+		app := fiber.New()
+		app.Use(fiberv3.New(l, fiberCfg))
+		app.Get("/ok", func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+		app.Get("/fail", func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusInternalServerError) })
+		return limitertest.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+			return app.Test(r)
+		})
+	})
+}
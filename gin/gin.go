@@ -1,22 +1,25 @@
-package limiter
+// Package gin adapts the limiter to Gin. It is a module of its own so that
+// only its users depend on Gin.
+package gin
 
 import (
-	"fmt"
 	"net/http"
-	"strconv"
-	"time"
 
+	"github.com/NarmadaWeb/limiter/v3"
 	"github.com/gin-gonic/gin"
 )
 
-type GinConfig struct {
+type Config struct {
 	KeyGenerator        func(c *gin.Context) string
 	LimitReachedHandler func(c *gin.Context)
 	ErrorHandler        func(c *gin.Context, err error)
 	Skipsuccessfull     bool
+	// Headers selects the rate limit headers, HeadersXRateLimit by default
+	Headers limiter.HeaderMode
 }
 
-func (l *Limiter) GinMiddleware(cfg GinConfig) gin.HandlerFunc {
+// New creates a Gin middleware counting requests against l.
+func New(l *limiter.Limiter, cfg Config) gin.HandlerFunc {
 	// Set defaults
 	if cfg.KeyGenerator == nil {
 		cfg.KeyGenerator = func(c *gin.Context) string {
@@ -45,18 +48,15 @@ func (l *Limiter) GinMiddleware(cfg GinConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		key := cfg.KeyGenerator(c)
 
-		allowed, remaining, reset, err := l.store.Take(l.ctx, key, l.config.MaxRequests, l.config.Window, l.config.Algorithm)
-		if err != nil {
+		o := l.Take(c.Request.Context(), key, func() limiter.RequestInfo { return limiter.HTTPRequestInfo(c.Request) })
+		if err := o.Err(); err != nil {
 			cfg.ErrorHandler(c, err)
 			return
 		}
 
-		c.Header("X-RateLimit-Limit", strconv.Itoa(l.config.MaxRequests))
-		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
-		c.Header("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
-		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", l.config.MaxRequests, int(time.Minute.Seconds())))
+		o.SetHeaders(c.Header, cfg.Headers)
 
-		if !allowed {
+		if o.Rejected() {
 			cfg.LimitReachedHandler(c)
 			return
 		}
@@ -64,7 +64,7 @@ func (l *Limiter) GinMiddleware(cfg GinConfig) gin.HandlerFunc {
 		c.Next()
 
 		if cfg.Skipsuccessfull && c.Writer.Status() < http.StatusBadRequest {
-			_ = l.store.Rollback(l.ctx, key)
+			_ = o.Rollback(c.Request.Context())
 		}
 	}
 }
@@ -0,0 +1,345 @@
+// Package grpc provides gRPC server and client interceptors for the
+// limiter. It is a module of its own so that only its users depend on
+// gRPC.
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/NarmadaWeb/limiter/v3"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Config configures the server interceptors.
+type Config struct {
+	// KeyGenerator extracts the key of a call, PeerKey by default
+	KeyGenerator func(ctx context.Context, fullMethod string) string
+	// LimitReachedHandler returns the error of a rejected call. By default
+	// it is codes.ResourceExhausted with a RetryInfo detail.
+	LimitReachedHandler func(ctx context.Context, fullMethod string, retryAfter time.Duration) error
+	// ErrorHandler returns the error of a call the limiter could not
+	// decide, codes.Unavailable by default
+	ErrorHandler    func(ctx context.Context, fullMethod string, err error) error
+	Skipsuccessfull bool
+	// Headers selects the rate limit metadata sent in the response
+	// headers, HeadersXRateLimit by default
+	Headers limiter.HeaderMode
+	// PerMessage counts every message a stream receives instead of the
+	// stream itself. A rejected message fails RecvMsg with the
+	// LimitReachedHandler error. Skipsuccessfull does not apply.
+	PerMessage bool
+}
+
+// PeerKey keys calls by the host of the peer address.
+func PeerKey(ctx context.Context, _ string) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// MethodKey keys calls by their full method name, so every method
+// shares one limit across all clients.
+func MethodKey(_ context.Context, fullMethod string) string {
+	return fullMethod
+}
+
+// MetadataKey keys calls by the first value of the incoming metadata
+// name, such as an API key header.
+func MetadataKey(name string) func(ctx context.Context, fullMethod string) string {
+	return func(ctx context.Context, _ string) string {
+		if values := metadata.ValueFromIncomingContext(ctx, name); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.KeyGenerator == nil {
+		cfg.KeyGenerator = PeerKey
+	}
+	if cfg.LimitReachedHandler == nil {
+		cfg.LimitReachedHandler = grpcLimitReached
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = grpcError
+	}
+	return cfg
+}
+
+func grpcLimitReached(_ context.Context, _ string, retryAfter time.Duration) error {
+	st := status.New(codes.ResourceExhausted, "rate limit exceeded")
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+func grpcError(_ context.Context, _ string, err error) error {
+	return status.Error(codes.Unavailable, "rate limit error: "+err.Error())
+}
+
+// UnaryServerInterceptor creates a unary server interceptor counting calls
+// against l.
+func UnaryServerInterceptor(l *limiter.Limiter, cfg Config) grpc.UnaryServerInterceptor {
+	cfg = cfg.withDefaults()
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		key := cfg.KeyGenerator(ctx, info.FullMethod)
+		o, err := take(ctx, l, cfg, info.FullMethod, key, func(md metadata.MD) error {
+			return grpc.SetHeader(ctx, md)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := handler(ctx, req)
+		if cfg.Skipsuccessfull && err == nil {
+			_ = o.Rollback(ctx)
+		}
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor creates a stream server interceptor counting
+// calls against l. Each stream counts as one request unless
+// Config.PerMessage is set.
+func StreamServerInterceptor(l *limiter.Limiter, cfg Config) grpc.StreamServerInterceptor {
+	cfg = cfg.withDefaults()
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		key := cfg.KeyGenerator(ctx, info.FullMethod)
+		if cfg.PerMessage {
+			return handler(srv, &limitedServerStream{ServerStream: ss, limiter: l, cfg: cfg, method: info.FullMethod, key: key})
+		}
+
+		o, err := take(ctx, l, cfg, info.FullMethod, key, ss.SetHeader)
+		if err != nil {
+			return err
+		}
+		err = handler(srv, ss)
+		if cfg.Skipsuccessfull && err == nil {
+			_ = o.Rollback(ctx)
+		}
+		return err
+	}
+}
+
+// limitedServerStream counts every message received.
+type limitedServerStream struct {
+	grpc.ServerStream
+	limiter     *limiter.Limiter
+	cfg         Config
+	method, key string
+}
+
+func (s *limitedServerStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	_, err := take(s.Context(), s.limiter, s.cfg, s.method, s.key, s.ServerStream.SetHeader)
+	return err
+}
+
+// take counts one call or message and returns the error ending it, if
+// any. The rate limit metadata is passed to setHeader, which fails once
+// the headers are sent; later messages of a stream then send none.
+func take(ctx context.Context, l *limiter.Limiter, cfg Config, method, key string, setHeader func(metadata.MD) error) (*limiter.Outcome, error) {
+	o := l.Take(ctx, key, func() limiter.RequestInfo { return requestInfo(ctx, method) })
+	if err := o.Err(); err != nil {
+		return o, cfg.ErrorHandler(ctx, method, err)
+	}
+
+	md := metadata.MD{}
+	// Retry-After is left to the RetryInfo of the LimitReachedHandler.
+	o.SetHeaders(func(k, v string) {
+		if k != "Retry-After" {
+			md.Set(k, v)
+		}
+	}, cfg.Headers)
+	if len(md) > 0 {
+		_ = setHeader(md)
+	}
+
+	if o.Rejected() {
+		return o, cfg.LimitReachedHandler(ctx, method, o.RetryAfter())
+	}
+	return o, nil
+}
+
+// requestInfo copies the metadata of a call. Path is the full method.
+func requestInfo(ctx context.Context, method string) limiter.RequestInfo {
+	info := limiter.RequestInfo{Method: "POST", Path: method}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		info.RemoteAddr = p.Addr.String()
+	}
+	if ua := metadata.ValueFromIncomingContext(ctx, "user-agent"); len(ua) > 0 {
+		info.UserAgent = ua[0]
+	}
+	return info
+}
+
+// ClientConfig configures the client interceptors.
+type ClientConfig struct {
+	// KeyGenerator extracts the key of an outgoing call, TargetKey by
+	// default
+	KeyGenerator func(ctx context.Context, fullMethod string, cc *grpc.ClientConn) string
+	// Wait blocks a call over the limit until it is admitted or its
+	// context is done. Otherwise the call fails fast with the
+	// LimitReachedHandler error.
+	Wait bool
+	// LimitReachedHandler returns the error of a call failed fast, by
+	// default codes.ResourceExhausted with a RetryInfo detail, as the
+	// server interceptors send.
+	LimitReachedHandler func(ctx context.Context, fullMethod string, retryAfter time.Duration) error
+	// ErrorHandler returns the error of a call the limiter could not
+	// decide, codes.Unavailable by default
+	ErrorHandler func(ctx context.Context, fullMethod string, err error) error
+	// AdaptToUpstream reads the rate limit response metadata, and the
+	// RetryInfo of codes.ResourceExhausted errors, like
+	// limiter.TransportConfig.AdaptToUpstream reads HTTP headers. Streams adapt
+	// once their first message is received.
+	AdaptToUpstream bool
+}
+
+// TargetKey keys outgoing calls by the target of their connection.
+func TargetKey(_ context.Context, _ string, cc *grpc.ClientConn) string {
+	return cc.Target()
+}
+
+func (cfg ClientConfig) withDefaults() ClientConfig {
+	if cfg.KeyGenerator == nil {
+		cfg.KeyGenerator = TargetKey
+	}
+	if cfg.LimitReachedHandler == nil {
+		cfg.LimitReachedHandler = grpcLimitReached
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = grpcError
+	}
+	return cfg
+}
+
+// UnaryClientInterceptor creates a unary client interceptor counting calls
+// against l.
+func UnaryClientInterceptor(l *limiter.Limiter, cfg ClientConfig) grpc.UnaryClientInterceptor {
+	cfg = cfg.withDefaults()
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		key := cfg.KeyGenerator(ctx, method, cc)
+		o, err := acquire(ctx, l, cfg, method, key)
+		if err != nil {
+			return err
+		}
+		if !cfg.AdaptToUpstream {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		var header metadata.MD
+		err = invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...)
+		o.Adapt(ctx, upstreamQuota(l, header, err))
+		return err
+	}
+}
+
+// StreamClientInterceptor creates a stream client interceptor counting
+// calls against l. Each stream counts as one request.
+func StreamClientInterceptor(l *limiter.Limiter, cfg ClientConfig) grpc.StreamClientInterceptor {
+	cfg = cfg.withDefaults()
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		key := cfg.KeyGenerator(ctx, method, cc)
+		o, err := acquire(ctx, l, cfg, method, key)
+		if err != nil {
+			return nil, err
+		}
+
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if !cfg.AdaptToUpstream {
+			return cs, err
+		}
+		if err != nil {
+			o.Adapt(ctx, upstreamQuota(l, nil, err))
+			return nil, err
+		}
+		return &adaptingClientStream{ClientStream: cs, limiter: l, o: o}, nil
+	}
+}
+
+// adaptingClientStream adapts to the upstream once the first message, or
+// the error ending the stream, is received.
+type adaptingClientStream struct {
+	grpc.ClientStream
+	limiter *limiter.Limiter
+	o       *limiter.Outcome
+	once    sync.Once
+}
+
+func (s *adaptingClientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	s.once.Do(func() {
+		// The headers have arrived, or never will, once RecvMsg returns.
+		header, _ := s.ClientStream.Header()
+		// A stream ending cleanly says nothing about the quota.
+		recvErr := err
+		if errors.Is(recvErr, io.EOF) {
+			recvErr = nil
+		}
+		s.o.Adapt(s.Context(), upstreamQuota(s.limiter, header, recvErr))
+	})
+	return err
+}
+
+// acquire takes one outgoing call and returns the error failing it, if
+// any.
+func acquire(ctx context.Context, l *limiter.Limiter, cfg ClientConfig, method, key string) (*limiter.Outcome, error) {
+	o, err := l.Acquire(ctx, key, cfg.Wait, func() limiter.RequestInfo { return limiter.RequestInfo{Method: "POST", Path: method} })
+	var exceeded *limiter.LimitExceededError
+	switch {
+	case err == nil:
+		return o, nil
+	case errors.As(err, &exceeded):
+		return o, cfg.LimitReachedHandler(ctx, method, exceeded.RetryAfter)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return o, status.FromContextError(err).Err()
+	default:
+		return o, cfg.ErrorHandler(ctx, method, err)
+	}
+}
+
+// upstreamQuota reads the rate limit metadata of header and the RetryInfo
+// of a codes.ResourceExhausted err.
+func upstreamQuota(l *limiter.Limiter, header metadata.MD, err error) limiter.UpstreamQuota {
+	q := l.ParseUpstreamQuota(func(key string) string {
+		if values := header.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	})
+
+	if st, ok := status.FromError(err); ok && st.Code() == codes.ResourceExhausted {
+		for _, detail := range st.Details() {
+			if info, ok := detail.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+				q.RetryAfter = max(q.RetryAfter, info.GetRetryDelay().AsDuration())
+			}
+		}
+		if q.RetryAfter > 0 && q.Remaining < 0 {
+			q.Remaining = 0
+		}
+	}
+	return q
+}
@@ -0,0 +1,64 @@
+package limiter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// HeaderMode selects the rate limit headers a middleware sends.
+// Retry-After is sent on every denial whatever the mode.
+type HeaderMode int
+
+const (
+	// HeadersXRateLimit sends X-RateLimit-Limit, X-RateLimit-Remaining,
+	// X-RateLimit-Reset as a Unix time and RateLimit-Policy (default).
+	HeadersXRateLimit HeaderMode = iota
+	// HeadersIETF sends RateLimit-Limit, RateLimit-Remaining,
+	// RateLimit-Reset in seconds and RateLimit-Policy, as in the IETF
+	// RateLimit header fields draft.
+	HeadersIETF
+	// HeadersNone sends no rate limit headers.
+	HeadersNone
+)
+
+func (m HeaderMode) String() string {
+	switch m {
+	case HeadersXRateLimit:
+		return "x-ratelimit"
+	case HeadersIETF:
+		return "ietf"
+	case HeadersNone:
+		return "none"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseHeaderMode parses the names returned by HeaderMode.String.
+func ParseHeaderMode(s string) (HeaderMode, error) {
+	for _, m := range []HeaderMode{HeadersXRateLimit, HeadersIETF, HeadersNone} {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return HeadersXRateLimit, fmt.Errorf("%w: unknown header mode %q", ErrInvalidConfig, s)
+}
+
+// writeHeaders sends the headers of mode for o through set.
+func (l *Limiter) writeHeaders(set func(key, value string), mode HeaderMode, o outcome) {
+	limit := o.limit
+	switch mode {
+	case HeadersXRateLimit:
+		set("X-RateLimit-Limit", strconv.Itoa(limit))
+		set("X-RateLimit-Remaining", strconv.Itoa(o.remaining))
+		set("X-RateLimit-Reset", strconv.FormatInt(o.reset.Unix(), 10))
+		set("RateLimit-Policy", o.state.info.Policy)
+	case HeadersIETF:
+		seconds := int64(math.Ceil(o.reset.Sub(l.clock.Now()).Seconds()))
+		set("RateLimit-Limit", strconv.Itoa(limit))
+		set("RateLimit-Remaining", strconv.Itoa(o.remaining))
+		set("RateLimit-Reset", strconv.FormatInt(max(seconds, 0), 10))
+		set("RateLimit-Policy", o.state.info.Policy)
+	}
+}
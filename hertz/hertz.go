@@ -0,0 +1,86 @@
+// Package hertz adapts the limiter to CloudWeGo Hertz. It is a module of
+// its own so that only its users depend on Hertz.
+package hertz
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+type Config struct {
+	KeyGenerator        func(c context.Context, ctx *app.RequestContext) string
+	LimitReachedHandler app.HandlerFunc
+	ErrorHandler        func(c context.Context, ctx *app.RequestContext, err error)
+	Skipsuccessfull     bool
+	// Headers selects the rate limit headers, HeadersXRateLimit by default
+	Headers limiter.HeaderMode
+}
+
+// New creates a Hertz middleware counting requests against l.
+func New(l *limiter.Limiter, cfg Config) app.HandlerFunc {
+	// Set defaults
+	if cfg.KeyGenerator == nil {
+		cfg.KeyGenerator = func(_ context.Context, ctx *app.RequestContext) string {
+			return ctx.ClientIP()
+		}
+	}
+	if cfg.LimitReachedHandler == nil {
+		cfg.LimitReachedHandler = func(_ context.Context, ctx *app.RequestContext) {
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, utils.H{
+				"error":   "rate limit exceeded",
+				"message": "Too many requests, please try again later",
+			})
+		}
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = func(_ context.Context, ctx *app.RequestContext, err error) {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.H{
+				"error":   "rate limit error",
+				"message": err.Error(),
+			})
+		}
+	}
+
+	return func(c context.Context, ctx *app.RequestContext) {
+		key := cfg.KeyGenerator(c, ctx)
+
+		o := l.Take(c, key, func() limiter.RequestInfo { return requestInfo(ctx) })
+		if err := o.Err(); err != nil {
+			cfg.ErrorHandler(c, ctx, err)
+			ctx.Abort()
+			return
+		}
+
+		o.SetHeaders(ctx.Response.Header.Set, cfg.Headers)
+
+		if o.Rejected() {
+			cfg.LimitReachedHandler(c, ctx)
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next(c)
+
+		if cfg.Skipsuccessfull && ctx.Response.StatusCode() < http.StatusBadRequest {
+			_ = o.Rollback(c)
+		}
+	}
+}
+
+// requestInfo copies the request metadata; Hertz reuses the buffers
+// behind its byte slices once the handler returns.
+func requestInfo(ctx *app.RequestContext) limiter.RequestInfo {
+	info := limiter.RequestInfo{
+		Method:    string(ctx.Method()),
+		Path:      string(ctx.Path()),
+		UserAgent: string(ctx.UserAgent()),
+	}
+	if addr := ctx.RemoteAddr(); addr != nil {
+		info.RemoteAddr = addr.String()
+	}
+	return info
+}
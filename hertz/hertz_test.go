@@ -0,0 +1,59 @@
+package hertz_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	limiterhertz "github.com/NarmadaWeb/limiter/hertz/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/NarmadaWeb/limiter/v3/limitertest"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+)
+
+func TestAdapterConformance(t *testing.T) {
+	limitertest.RunAdapterConformance(t, func(t *testing.T, l *limiter.Limiter, cfg limitertest.AdapterConfig) http.RoundTripper {
+		hertzCfg := limiterhertz.Config{Skipsuccessfull: cfg.Skipsuccessfull, Headers: cfg.Headers}
+		if cfg.KeyHeader != "" {
+			hertzCfg.KeyGenerator = func(_ context.Context, ctx *app.RequestContext) string {
+				return string(ctx.GetHeader(cfg.KeyHeader))
+			}
+		}
+		if cfg.CustomHandlers {
+			hertzCfg.LimitReachedHandler = func(_ context.Context, ctx *app.RequestContext) {
+				ctx.AbortWithStatus(limitertest.StatusLimitReached)
+			}
+			hertzCfg.ErrorHandler = func(_ context.Context, ctx *app.RequestContext, _ error) {
+				ctx.AbortWithStatus(limitertest.StatusError)
+			}
+		}
+
+		h := server.New()
+		h.Use(limiterhertz.New(l, hertzCfg))
+		h.GET("/ok", func(_ context.Context, ctx *app.RequestContext) { ctx.Status(http.StatusOK) })
+		h.GET("/fail", func(_ context.Context, ctx *app.RequestContext) { ctx.Status(http.StatusInternalServerError) })
+
+		return limitertest.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+			var headers []ut.Header
+			for k := range r.Header {
+				headers = append(headers, ut.Header{Key: k, Value: r.Header.Get(k)})
+			}
+			w := ut.PerformRequest(h.Engine, r.Method, r.URL.Path, nil, headers...)
+
+			res := w.Result()
+			resp := &http.Response{
+				StatusCode: res.StatusCode(),
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader(string(res.Body()))),
+			}
+			res.Header.VisitAll(func(k, v []byte) {
+				resp.Header.Add(string(k), string(v))
+			})
+			return resp, nil
+		})
+	})
+}
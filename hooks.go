@@ -0,0 +1,192 @@
+package limiter
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Hooks are framework-agnostic callbacks run for every decision. They run
+// on the request path, so slow work such as shipping events to a SIEM
+// belongs in a goroutine; the Decision is a plain value that is safe to
+// hand over.
+type Hooks struct {
+	// OnAllowed is called for every admitted request.
+	OnAllowed func(Decision)
+	// OnLimited is called for every denied request.
+	OnLimited func(Decision)
+	// OnError is called when the store fails, before the FailurePolicy applies.
+	OnError func(Decision)
+	// OnFallback is called when FailOpen or FallbackToLocal decided the request.
+	OnFallback func(Decision)
+}
+
+func (h Hooks) enabled() bool {
+	return h.OnAllowed != nil || h.OnLimited != nil || h.OnError != nil || h.OnFallback != nil
+}
+
+// Decision describes how a request was handled. It holds no reference to
+// the request, so it may outlive it.
+type Decision struct {
+	Limiter   string
+	Key       string
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	// Policy is the RateLimit-Policy value, e.g. "100;w=60"
+	Policy    string
+	Algorithm Algorithm
+	// FailurePolicy is the configured policy; Degraded reports whether it
+	// decided this request.
+	FailurePolicy FailurePolicy
+	Degraded      bool
+	// Err is the store error, if any
+	Err     error
+	Request RequestInfo
+	// DryRun is set when the limiter runs in DryRun mode, where a denied
+	// request is let through anyway
+	DryRun bool
+	// Shadow names the ShadowLimit that made this decision, empty for the
+	// enforced limit
+	Shadow string
+}
+
+// RequestInfo is the request metadata copied into a Decision. It is empty
+// for Limiter.Allow.
+type RequestInfo struct {
+	Method     string
+	Path       string
+	RemoteAddr string
+	UserAgent  string
+}
+
+// HTTPRequestInfo copies the metadata of r, for adapters of net/http
+// based frameworks.
+func HTTPRequestInfo(r *http.Request) RequestInfo {
+	return RequestInfo{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	}
+}
+
+// LogValue implements slog.LogValuer.
+func (d Decision) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("limiter", d.Limiter),
+		slog.String("key", d.Key),
+		slog.Bool("allowed", d.Allowed),
+		slog.Int("limit", d.Limit),
+		slog.Int("remaining", d.Remaining),
+		slog.Time("reset", d.Reset),
+		slog.String("policy", d.Policy),
+	}
+	if d.DryRun {
+		attrs = append(attrs, slog.Bool("dry_run", true))
+	}
+	if d.Shadow != "" {
+		attrs = append(attrs, slog.String("shadow", d.Shadow))
+	}
+	if d.Degraded {
+		attrs = append(attrs, slog.String("failure_policy", d.FailurePolicy.String()))
+	}
+	if d.Err != nil {
+		attrs = append(attrs, slog.String("error", d.Err.Error()))
+	}
+	if d.Request != (RequestInfo{}) {
+		attrs = append(attrs, slog.Group("request",
+			slog.String("method", d.Request.Method),
+			slog.String("path", d.Request.Path),
+			slog.String("remote_addr", d.Request.RemoteAddr),
+			slog.String("user_agent", d.Request.UserAgent),
+		))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// SlogHooks returns Hooks logging to logger: allowed requests at debug,
+// limited and fallback decisions at warn and store errors at error.
+func SlogHooks(logger *slog.Logger) Hooks {
+	log := func(level slog.Level, msg string) func(Decision) {
+		return func(d Decision) {
+			ctx := context.Background()
+			if logger.Enabled(ctx, level) {
+				logger.LogAttrs(ctx, level, msg, slog.Any("decision", d))
+			}
+		}
+	}
+	return Hooks{
+		OnAllowed:  log(slog.LevelDebug, "rate limit allowed"),
+		OnLimited:  log(slog.LevelWarn, "rate limit exceeded"),
+		OnError:    log(slog.LevelError, "rate limit store error"),
+		OnFallback: log(slog.LevelWarn, "rate limit failure policy applied"),
+	}
+}
+
+// outcome is what decide concluded for one request.
+type outcome struct {
+	allowed   bool
+	limit     int
+	remaining int
+	reset     time.Time
+	// err is returned to the adapter, storeErr is what the store reported
+	err      error
+	storeErr error
+	degraded bool
+	// rejected is set by take when the request must be denied, which a
+	// DryRun limiter never does
+	rejected bool
+	// shadows reports which shadow limits granted the request
+	shadows []bool
+	// state is the configuration the request was decided with
+	state *limiterState
+}
+
+// emit runs the hooks for o, decided by the limit described by info.
+func (l *Limiter) emit(info LimiterInfo, key string, request func() RequestInfo, o outcome) {
+	cfg := o.state.config
+	if !cfg.Hooks.enabled() {
+		return
+	}
+
+	// Adapters such as fiber may hand out keys backed by reused buffers.
+	d := Decision{
+		Limiter:       cfg.Name,
+		Key:           strings.Clone(key),
+		Allowed:       o.allowed,
+		Limit:         o.limit,
+		Remaining:     o.remaining,
+		Reset:         o.reset,
+		Policy:        info.Policy,
+		Algorithm:     info.Algorithm,
+		FailurePolicy: cfg.FailurePolicy,
+		Degraded:      o.degraded,
+		Err:           o.storeErr,
+		DryRun:        info.DryRun,
+		Shadow:        info.Shadow,
+	}
+	if request != nil {
+		d.Request = request()
+	}
+
+	hooks := cfg.Hooks
+	if o.storeErr != nil && hooks.OnError != nil {
+		hooks.OnError(d)
+	}
+	if o.degraded && hooks.OnFallback != nil {
+		hooks.OnFallback(d)
+	}
+	if o.err != nil {
+		return
+	}
+	if o.allowed && hooks.OnAllowed != nil {
+		hooks.OnAllowed(d)
+	}
+	if !o.allowed && hooks.OnLimited != nil {
+		hooks.OnLimited(d)
+	}
+}
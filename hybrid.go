@@ -0,0 +1,239 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHybridBatchSize = 10
+	defaultHybridLeaseTTL  = time.Second
+	hybridSweepInterval    = time.Minute
+)
+
+// HybridStoreOptions configures a HybridStore.
+type HybridStoreOptions struct {
+	// BatchSize is the number of requests leased from the remote store in a
+	// single call. Defaults to 10.
+	BatchSize int
+	// LeaseTTL bounds how long leased requests may be spent locally. A lease
+	// never outlives the reset time reported by the remote store. Defaults to 1 second.
+	LeaseTTL time.Duration
+	// Clock defaults to the system clock
+	Clock Clock
+}
+
+// HybridStore keeps a local lease of requests per key in front of a remote
+// store such as the Store of the redis module and only calls the remote
+// store when the lease is exhausted. Denied keys are cached locally until
+// their reset time, so a blocked client costs no remote calls.
+//
+// Leased requests are counted by the remote store when they are leased, so
+// the limit is never exceeded by requests that are spent before the remote
+// window moves on. With fixed-window this always holds because a lease ends
+// at the window reset. With sliding-window and token-bucket a lease may be
+// spent up to LeaseTTL after it was counted, so in any window a key admits at
+// most MaxRequests + (BatchSize-1) requests per HybridStore sharing the
+// remote store. Unspent leases expire, which can under-admit by the same bound.
+//
+// The remote store should implement BatchStore; otherwise every lease holds
+// a single request and only the denial cache saves remote calls.
+type HybridStore struct {
+	remote Store
+	opts   HybridStoreOptions
+
+	mu        sync.Mutex
+	leases    map[string]*hybridLease
+	pending   map[string]chan struct{}
+	nextSweep time.Time
+}
+
+func hybridLeaseKey(algorithm Algorithm, key string) string {
+	return string(algorithm) + ":" + key
+}
+
+type hybridLease struct {
+	tokens    int
+	remaining int
+	denied    bool
+	reset     time.Time
+	expiresAt time.Time
+}
+
+func NewHybridStore(remote Store, opts HybridStoreOptions) *HybridStore {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultHybridBatchSize
+	}
+	if opts.LeaseTTL <= 0 {
+		opts.LeaseTTL = defaultHybridLeaseTTL
+	}
+	opts.Clock = clockOrDefault(opts.Clock)
+	return &HybridStore{
+		remote:  remote,
+		opts:    opts,
+		leases:  make(map[string]*hybridLease),
+		pending: make(map[string]chan struct{}),
+	}
+}
+
+func (h *HybridStore) Take(ctx context.Context, key string, maxRequests int, window time.Duration, algorithm Algorithm) (bool, int, time.Time, error) {
+	leaseKey := hybridLeaseKey(algorithm, key)
+
+	for {
+		now := h.opts.Clock.Now()
+
+		h.mu.Lock()
+		h.sweep(now)
+		if lease, ok := h.leases[leaseKey]; ok && now.Before(lease.expiresAt) {
+			if lease.denied {
+				h.mu.Unlock()
+				return false, 0, lease.reset, nil
+			}
+			if lease.tokens > 0 {
+				lease.tokens--
+				remaining := lease.remaining + lease.tokens
+				h.mu.Unlock()
+				return true, remaining, lease.reset, nil
+			}
+		}
+
+		// Only one lease per key is in flight; everyone else waits for it
+		// and then looks at the local lease again.
+		if pending, ok := h.pending[leaseKey]; ok {
+			h.mu.Unlock()
+			select {
+			case <-pending:
+				continue
+			case <-ctx.Done():
+				return false, 0, now.Add(window), ctx.Err()
+			}
+		}
+		done := make(chan struct{})
+		h.pending[leaseKey] = done
+		h.mu.Unlock()
+
+		granted, remaining, reset, err := h.lease(ctx, key, maxRequests, window, algorithm)
+		return h.settle(leaseKey, done, now, granted, remaining, reset, err)
+	}
+}
+
+// settle records the outcome of a remote lease and releases its waiters.
+func (h *HybridStore) settle(leaseKey string, done chan struct{}, now time.Time, granted, remaining int, reset time.Time, err error) (bool, int, time.Time, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.pending, leaseKey)
+	close(done)
+
+	if err != nil {
+		return false, 0, reset, err
+	}
+
+	if granted == 0 {
+		h.leases[leaseKey] = &hybridLease{
+			denied:    true,
+			reset:     reset,
+			expiresAt: reset,
+		}
+		return false, 0, reset, nil
+	}
+
+	expiresAt := now.Add(h.opts.LeaseTTL)
+	if reset.Before(expiresAt) {
+		expiresAt = reset
+	}
+	h.leases[leaseKey] = &hybridLease{
+		tokens:    granted - 1,
+		remaining: remaining,
+		reset:     reset,
+		expiresAt: expiresAt,
+	}
+	return true, remaining + granted - 1, reset, nil
+}
+
+// lease asks the remote store for a batch of requests.
+func (h *HybridStore) lease(ctx context.Context, key string, maxRequests int, window time.Duration, algorithm Algorithm) (int, int, time.Time, error) {
+	if batch, ok := h.remote.(BatchStore); ok {
+		return batch.TakeN(ctx, key, h.opts.BatchSize, maxRequests, window, algorithm)
+	}
+
+	allowed, remaining, reset, err := h.remote.Take(ctx, key, maxRequests, window, algorithm)
+	if err != nil || !allowed {
+		return 0, remaining, reset, err
+	}
+	return 1, remaining, reset, nil
+}
+
+// sweep drops expired leases once per hybridSweepInterval. It must be called
+// with h.mu held.
+func (h *HybridStore) sweep(now time.Time) {
+	if now.Before(h.nextSweep) {
+		return
+	}
+	for k, lease := range h.leases {
+		if !now.Before(lease.expiresAt) {
+			delete(h.leases, k)
+		}
+	}
+	h.nextSweep = now.Add(hybridSweepInterval)
+}
+
+// Rollback returns the request to the local lease when one is active,
+// otherwise to the remote store.
+func (h *HybridStore) Rollback(ctx context.Context, key string) error {
+	now := h.opts.Clock.Now()
+
+	h.mu.Lock()
+	for _, algorithm := range Algorithms() {
+		lease, ok := h.leases[hybridLeaseKey(algorithm, key)]
+		if ok && !lease.denied && now.Before(lease.expiresAt) {
+			lease.tokens++
+			h.mu.Unlock()
+			return nil
+		}
+	}
+	h.mu.Unlock()
+
+	return h.remote.Rollback(ctx, key)
+}
+
+func (h *HybridStore) Get(ctx context.Context, key string) (int, error) {
+	return h.remote.Get(ctx, key)
+}
+
+func (h *HybridStore) Set(ctx context.Context, key string, value int, expiration time.Duration) error {
+	h.forget(key)
+	return h.remote.Set(ctx, key, value, expiration)
+}
+
+// Named implements NamedStore when the remote store does. The returned
+// store holds leases of its own.
+func (h *HybridStore) Named(name string) Store {
+	named, ok := h.remote.(NamedStore)
+	if !ok {
+		return h
+	}
+	return NewHybridStore(named.Named(name), h.opts)
+}
+
+func (h *HybridStore) Close() error {
+	h.mu.Lock()
+	h.leases = make(map[string]*hybridLease)
+	h.mu.Unlock()
+
+	if closer, ok := h.remote.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// forget drops every lease held for key.
+func (h *HybridStore) forget(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, algorithm := range Algorithms() {
+		delete(h.leases, hybridLeaseKey(algorithm, key))
+	}
+}
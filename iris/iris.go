@@ -0,0 +1,70 @@
+// Package iris adapts the limiter to Iris. It is a module of its own so
+// that only its users depend on Iris.
+package iris
+
+import (
+	"net/http"
+
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/kataras/iris/v12"
+)
+
+type Config struct {
+	KeyGenerator        func(ctx iris.Context) string
+	LimitReachedHandler iris.Handler
+	ErrorHandler        func(ctx iris.Context, err error)
+	Skipsuccessfull     bool
+	// Headers selects the rate limit headers, HeadersXRateLimit by default
+	Headers limiter.HeaderMode
+}
+
+// New creates an Iris middleware counting requests against l.
+func New(l *limiter.Limiter, cfg Config) iris.Handler {
+	// Set defaults
+	if cfg.KeyGenerator == nil {
+		cfg.KeyGenerator = func(ctx iris.Context) string {
+			return ctx.RemoteAddr()
+		}
+	}
+	if cfg.LimitReachedHandler == nil {
+		cfg.LimitReachedHandler = func(ctx iris.Context) {
+			ctx.StopWithJSON(http.StatusTooManyRequests, iris.Map{
+				"error":   "rate limit exceeded",
+				"message": "Too many requests, please try again later",
+			})
+		}
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = func(ctx iris.Context, err error) {
+			ctx.StopWithJSON(http.StatusInternalServerError, iris.Map{
+				"error":   "rate limit error",
+				"message": err.Error(),
+			})
+		}
+	}
+
+	return func(ctx iris.Context) {
+		key := cfg.KeyGenerator(ctx)
+
+		o := l.Take(ctx.Request().Context(), key, func() limiter.RequestInfo { return limiter.HTTPRequestInfo(ctx.Request()) })
+		if err := o.Err(); err != nil {
+			cfg.ErrorHandler(ctx, err)
+			ctx.StopExecution()
+			return
+		}
+
+		o.SetHeaders(ctx.Header, cfg.Headers)
+
+		if o.Rejected() {
+			cfg.LimitReachedHandler(ctx)
+			ctx.StopExecution()
+			return
+		}
+
+		ctx.Next()
+
+		if cfg.Skipsuccessfull && ctx.GetStatusCode() < http.StatusBadRequest {
+			_ = o.Rollback(ctx.Request().Context())
+		}
+	}
+}
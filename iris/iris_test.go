@@ -0,0 +1,37 @@
+package iris_test
+
+import (
+	"net/http"
+	"testing"
+
+	limiteriris "github.com/NarmadaWeb/limiter/iris/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/NarmadaWeb/limiter/v3/limitertest"
+	"github.com/kataras/iris/v12"
+)
+
+func TestAdapterConformance(t *testing.T) {
+	limitertest.RunAdapterConformance(t, func(t *testing.T, l *limiter.Limiter, cfg limitertest.AdapterConfig) http.RoundTripper {
+		irisCfg := limiteriris.Config{Skipsuccessfull: cfg.Skipsuccessfull, Headers: cfg.Headers}
+		if cfg.KeyHeader != "" {
+			irisCfg.KeyGenerator = func(ctx iris.Context) string { return ctx.GetHeader(cfg.KeyHeader) }
+		}
+		if cfg.CustomHandlers {
+			irisCfg.LimitReachedHandler = func(ctx iris.Context) {
+				ctx.StopWithStatus(limitertest.StatusLimitReached)
+			}
+			irisCfg.ErrorHandler = func(ctx iris.Context, _ error) {
+				ctx.StopWithStatus(limitertest.StatusError)
+			}
+		}
+
+		app := iris.New()
+		app.Use(limiteriris.New(l, irisCfg))
+		app.Get("/ok", func(ctx iris.Context) { ctx.StatusCode(http.StatusOK) })
+		app.Get("/fail", func(ctx iris.Context) { ctx.StatusCode(http.StatusInternalServerError) })
+		if err := app.Build(); err != nil {
+			t.Fatal(err)
+		}
+		return limitertest.HandlerTransport(app)
+	})
+}
@@ -2,92 +2,299 @@ package limiter
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"slices"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
-
-	"github.com/redis/go-redis/v9"
 )
 
 // Config holds the core configuration for the rate limiter.
 // Framework-specific settings are handled in their respective middleware generators.
 type Config struct {
-	// Redis Configuration for starting limiter
-	RedisClient *redis.Client
-	RedisURL    string
+	// Name identifies the limiter to observers, in shadow keys and in
+	// stores implementing NamedStore
+	Name string
+
+	// Store counts the requests, a MemoryStore by default. The redis
+	// module provides a store shared by every instance.
+	Store Store
 
 	// Rate Limiter configuration
 	MaxRequests int
 	Window      time.Duration
 
-	// value "token-bucket", "sliding-window" and "fixed-window"
-	Algorithm string
+	// TokenBucket, SlidingWindow, FixedWindow or a registered Strategy
+	Algorithm Algorithm
+
+	// Behaviour when the store is unavailable, FailClosed by default
+	FailurePolicy FailurePolicy
+	// FallbackScale scales MaxRequests for the FallbackToLocal store, e.g. 0.25
+	// when four instances normally share the limit. Defaults to 1.
+	FallbackScale float64
+	// RecoveryInterval is how long FallbackToLocal stays on the local store
+	// before trying the primary store again. Defaults to 5 seconds.
+	RecoveryInterval time.Duration
+	// OnStoreHealthChange is called when the store starts failing or recovers
+	OnStoreHealthChange func(healthy bool, err error)
+
+	// CircuitBreaker wraps the store in a CircuitBreakerStore when set
+	CircuitBreaker *CircuitBreakerOptions
+
+	// Clock is shared with the stores the limiter creates, defaults to the system clock
+	Clock Clock
+
+	// Observer receives metrics and tracing events, see the metrics package
+	Observer Observer
+	// Hooks are called for every decision, see SlogHooks
+	Hooks Hooks
+
+	// DryRun counts requests and reports the would-be decision in headers,
+	// hooks and metrics, but never rejects a request
+	DryRun bool
+	// Shadow limits are evaluated against the same traffic without ever
+	// rejecting a request
+	Shadow []ShadowLimit
 }
 
 type Limiter struct {
 	store      Store
-	config     Config
+	migrator   Migrator
+	health     *storeHealth
+	clock      Clock
+	observer   Observer
+	state      atomic.Pointer[limiterState]
+	pauses     sync.Map     // key → time.Time an upstream asked to wait until
+	pauseSweep atomic.Int64 // Unix nanoseconds of the next sweep of pauses
+	mu         sync.Mutex   // serializes UpdateConfig
 	ctx        context.Context
 	cancelfunc context.CancelFunc
 }
 
 func New(config Config) (*Limiter, error) {
 	if err := validateConfig(&config); err != nil {
-		return nil, fmt.Errorf("invalid config: %w", err)
+		return nil, err
 	}
 
+	config.Clock = clockOrDefault(config.Clock)
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// Initialize store
-	store, err := initStore(ctx, config)
-	if err != nil {
-		cancel()
-		return nil, fmt.Errorf("store initialization failed: %w", err)
-	}
+	store := initStore(ctx, config)
 
-	return &Limiter{
+	l := &Limiter{
 		store:      store,
-		config:     config,
+		health:     newStoreHealth(config.RecoveryInterval, config.OnStoreHealthChange),
+		clock:      config.Clock,
+		observer:   config.Observer,
 		ctx:        ctx,
 		cancelfunc: cancel,
-	}, nil
+	}
+	if l.observer == nil {
+		l.observer = nopObserver{}
+	}
+	l.migrator, _ = store.(Migrator)
+	st := l.newState(config, nil)
+	l.state.Store(st)
+	if so, ok := l.observer.(StoreObserver); ok {
+		so.ObserveStore(st.info, store)
+	}
+	return l, nil
 }
 
 func (l *Limiter) Close() error {
 	l.cancelfunc()
 
+	if so, ok := l.observer.(StoreObserver); ok {
+		so.ForgetStore(l.state.Load().info, l.store)
+	}
+	if fallback := l.state.Load().fallback; fallback != nil {
+		_ = fallback.Close()
+	}
 	if closer, ok := l.store.(interface{ Close() error }); ok {
 		return closer.Close()
 	}
 	return nil
 }
 
+// StoreHealthy reports whether the last call to the store succeeded.
+func (l *Limiter) StoreHealthy() bool {
+	return l.health.isHealthy()
+}
+
+// Store returns the store counting requests, wrapped in a
+// CircuitBreakerStore when Config.CircuitBreaker is set.
+func (l *Limiter) Store() Store {
+	return l.store
+}
+
+// take consumes one request for key, applying the configured FailurePolicy
+// when the store fails, and counts it against the shadow limits. Every
+// middleware goes through it and rejects the request only if o.rejected.
+func (l *Limiter) take(ctx context.Context, key string, request func() RequestInfo) outcome {
+	// One snapshot per request, so UpdateConfig never mixes two configs.
+	st := l.state.Load()
+	o := l.decide(ctx, st, key)
+	o.state = st
+	if o.err == nil {
+		l.observer.Decision(ctx, st.info, o.allowed)
+	}
+	l.emit(st.info, key, request, o)
+	o.rejected = !o.allowed && !st.config.DryRun
+	o.shadows = l.takeShadows(ctx, st, key, request)
+	return o
+}
+
+func (l *Limiter) decide(ctx context.Context, st *limiterState, key string) outcome {
+	cfg := st.config
+	now := l.clock.Now()
+	if st.fallback != nil && l.health.degraded(now) {
+		l.observer.PolicyApplied(ctx, st.info, FallbackToLocal, nil)
+		return l.takeFallback(ctx, st, key, nil)
+	}
+
+	storeCtx, done := l.observer.StoreTake(ctx, st.info)
+	if now.Before(st.migrateUntil) {
+		// Best effort: if the store is failing, the Take below fails too.
+		_ = l.migrator.Migrate(storeCtx, key, st.migrateFrom, cfg.Algorithm, cfg.MaxRequests, cfg.Window)
+	}
+	allowed, remaining, reset, err := l.store.Take(storeCtx, key, cfg.MaxRequests, cfg.Window, cfg.Algorithm)
+	done(TakeResult{Allowed: allowed, Remaining: remaining, Reset: reset, Err: err})
+	if err == nil {
+		l.health.recover()
+		return outcome{allowed: allowed, limit: cfg.MaxRequests, remaining: remaining, reset: reset}
+	}
+
+	if ctx.Err() != nil {
+		// The caller gave up: the store is not to blame and the request
+		// needs no decision.
+		return outcome{limit: cfg.MaxRequests, reset: reset, err: err}
+	}
+
+	l.health.fail(now, err)
+	switch cfg.FailurePolicy {
+	case FailOpen:
+		l.observer.PolicyApplied(ctx, st.info, FailOpen, err)
+		return outcome{
+			allowed:   true,
+			limit:     cfg.MaxRequests,
+			remaining: cfg.MaxRequests,
+			reset:     now.Add(cfg.Window),
+			storeErr:  err,
+			degraded:  true,
+		}
+	case FallbackToLocal:
+		l.observer.PolicyApplied(ctx, st.info, FallbackToLocal, err)
+		return l.takeFallback(ctx, st, key, err)
+	default:
+		return outcome{limit: cfg.MaxRequests, reset: reset, err: err, storeErr: err}
+	}
+}
+
+// takeFallback counts the request in the local store. storeErr is the
+// primary store error that caused it, if any.
+func (l *Limiter) takeFallback(ctx context.Context, st *limiterState, key string, storeErr error) outcome {
+	cfg := st.config
+	scale := cfg.FallbackScale
+	if scale == 0 {
+		scale = 1
+	}
+	limit := max(int(float64(cfg.MaxRequests)*scale), 1)
+	allowed, remaining, reset, err := st.fallback.Take(ctx, key, limit, cfg.Window, cfg.Algorithm)
+	return outcome{
+		allowed:   allowed,
+		limit:     limit,
+		remaining: remaining,
+		reset:     reset,
+		err:       err,
+		storeErr:  storeErr,
+		degraded:  true,
+	}
+}
+
+// Allow consumes one request for key outside of any HTTP framework. It
+// returns nil when the request is admitted, a *LimitExceededError when key
+// is over its limit, or the store error left after the FailurePolicy.
+func (l *Limiter) Allow(ctx context.Context, key string) error {
+	_, err := l.acquire(ctx, key, false, nil)
+	return err
+}
+
+// retryAfter returns the Retry-After header value, in whole seconds, for a
+// request denied until reset.
+func (l *Limiter) retryAfter(reset time.Time) string {
+	seconds := int64(math.Ceil(reset.Sub(l.clock.Now()).Seconds()))
+	return strconv.FormatInt(max(seconds, 0), 10)
+}
+
+// rollback returns the request take counted as o to whichever store is
+// currently counting. A request denied in DryRun was never counted.
+func (l *Limiter) rollback(ctx context.Context, key string, o outcome) error {
+	l.rollbackShadows(ctx, o.state, key, o.shadows)
+	if !o.allowed {
+		return nil
+	}
+
+	var err error
+	if o.state.fallback != nil && !l.health.isHealthy() {
+		err = o.state.fallback.Rollback(ctx, key)
+	} else {
+		err = l.store.Rollback(ctx, key)
+	}
+	l.observer.Rollback(ctx, o.state.info, err)
+	return err
+}
+
 // Helper functions
+// validateConfig reports every invalid field as a *ConfigError.
 func validateConfig(cfg *Config) error {
+	errs := &ConfigError{}
 	if cfg.MaxRequests <= 0 {
-		return errors.New("maxRequests must be positive")
+		errs.add("MaxRequests", "must be positive", nil)
 	}
 	if cfg.Window <= 0 {
-		return errors.New("window duration must be positive")
+		errs.add("Window", "must be positive", nil)
 	}
-	if !slices.Contains([]string{"token-bucket", "sliding-window", "fixed-window"}, cfg.Algorithm) {
-		return errors.New("invalid algorithm")
+	if !cfg.Algorithm.Valid() {
+		errs.add("Algorithm", unknownAlgorithm(cfg.Algorithm), ErrInvalidAlgorithm)
 	}
-	return nil
+	if cfg.FailurePolicy < FailClosed || cfg.FailurePolicy > FallbackToLocal {
+		errs.add("FailurePolicy", fmt.Sprintf("unknown policy %d", cfg.FailurePolicy), nil)
+	}
+	if cfg.FallbackScale < 0 {
+		errs.add("FallbackScale", "must not be negative", nil)
+	}
+	if cfg.RecoveryInterval < 0 {
+		errs.add("RecoveryInterval", "must not be negative", nil)
+	}
+	validateShadows(cfg.Shadow, errs)
+	return errs.orNil()
 }
 
-func initStore(ctx context.Context, config Config) (Store, error) {
-	switch {
-	case config.RedisClient != nil:
-		return NewRedisStore(config.RedisClient), nil
-	case config.RedisURL != "":
-		rdb := redis.NewClient(&redis.Options{Addr: config.RedisURL})
-		if err := rdb.Ping(ctx).Err(); err != nil {
-			return nil, fmt.Errorf("redis connection failed: %w", err)
+// initStore starts the janitor of a MemoryStore before wrapping it, so
+// expired keys are swept behind a CircuitBreakerStore too.
+func initStore(ctx context.Context, config Config) Store {
+	store := newStore(config)
+	if memory, ok := store.(*MemoryStore); ok {
+		memory.StartJanitor(ctx)
+	}
+	if config.CircuitBreaker != nil {
+		opts := *config.CircuitBreaker
+		if opts.Clock == nil {
+			opts.Clock = config.Clock
 		}
-		return NewRedisStore(rdb), nil
-	default:
-		return NewMemoryStore(), nil
+		store = NewCircuitBreakerStore(store, opts)
+	}
+	return store
+}
+
+func newStore(config Config) Store {
+	if named, ok := config.Store.(NamedStore); ok && config.Name != "" {
+		return named.Named(config.Name)
+	}
+	if config.Store != nil {
+		return config.Store
 	}
+	return NewMemoryStoreWithOptions(MemoryStoreOptions{Clock: config.Clock})
 }
@@ -0,0 +1,297 @@
+package limitertest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NarmadaWeb/limiter/v3"
+)
+
+// Statuses answered by the handlers an adapter installs for
+// AdapterConfig.CustomHandlers.
+const (
+	StatusLimitReached = http.StatusTeapot
+	StatusError        = http.StatusBadGateway
+)
+
+// AdapterConfig describes the adapter RunAdapterConformance asks for. The
+// newAdapter function translates it into the adapter's own Config.
+type AdapterConfig struct {
+	// KeyHeader, when set, names the request header KeyGenerator must
+	// return. Otherwise the adapter's default key is used.
+	KeyHeader string
+	// CustomHandlers asks for a LimitReachedHandler answering
+	// StatusLimitReached and an ErrorHandler answering StatusError.
+	CustomHandlers  bool
+	Skipsuccessfull bool
+	Headers         limiter.HeaderMode
+}
+
+// RoundTripFunc serves a request with a function, for frameworks tested
+// without a network listener.
+type RoundTripFunc func(r *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(r).
+func (f RoundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// HandlerTransport serves every request with h, for adapters of net/http
+// based frameworks.
+func HandlerTransport(h http.Handler) http.RoundTripper {
+	return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Result(), nil
+	})
+}
+
+// RunAdapterConformance checks that a framework adapter honors the options
+// every built-in adapter supports: the limit and its headers, the key
+// generator and the keys outliving their request, the limit reached and error handlers, skipping successful
+// requests, the header modes and the request metadata given to hooks.
+//
+// newAdapter builds a server on the framework with the adapter in front of
+// two routes, GET /ok answering 200 OK and GET /fail answering 500
+// Internal Server Error, and returns a transport serving requests with it.
+// It is called once per check with a fresh limiter.
+func RunAdapterConformance(t *testing.T, newAdapter func(t *testing.T, l *limiter.Limiter, cfg AdapterConfig) http.RoundTripper) {
+	t.Helper()
+
+	s := &adapterSuite{newAdapter: newAdapter}
+	t.Run("Limit", s.testLimit)
+	t.Run("KeyGenerator", s.testKeyGenerator)
+	t.Run("KeysOutliveRequests", s.testKeysOutliveRequests)
+	t.Run("LimitReachedHandler", s.testLimitReachedHandler)
+	t.Run("ErrorHandler", s.testErrorHandler)
+	t.Run("Skipsuccessfull", s.testSkipSuccessful)
+	t.Run("Headers", s.testHeaders)
+	t.Run("Hooks", s.testHooks)
+}
+
+type adapterSuite struct {
+	newAdapter func(t *testing.T, l *limiter.Limiter, cfg AdapterConfig) http.RoundTripper
+}
+
+// adapterLimit is the limit every check runs with.
+const adapterLimit = 2
+
+func (s *adapterSuite) serve(t *testing.T, lcfg limiter.Config, cfg AdapterConfig) http.RoundTripper {
+	t.Helper()
+	_, rt := s.serveLimiter(t, lcfg, cfg)
+	return rt
+}
+
+// serveLimiter is serve returning the limiter as well.
+func (s *adapterSuite) serveLimiter(t *testing.T, lcfg limiter.Config, cfg AdapterConfig) (*limiter.Limiter, http.RoundTripper) {
+	t.Helper()
+	lcfg.MaxRequests = adapterLimit
+	lcfg.Window = time.Minute
+	lcfg.Algorithm = limiter.FixedWindow
+	lcfg.Clock = limiter.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	l, err := limiter.New(lcfg)
+	if err != nil {
+		t.Fatalf("limiter.New returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	return l, s.newAdapter(t, l, cfg)
+}
+
+// get sends GET path with the given headers, a pair of strings each, and
+// returns the response with its body read.
+func get(t *testing.T, rt http.RoundTripper, path string, header ...string) *http.Response {
+	t.Helper()
+	r, err := http.NewRequest(http.MethodGet, "http://limiter.test"+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.RemoteAddr = "192.0.2.1:1234"
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	resp, err := rt.RoundTrip(r)
+	if err != nil {
+		t.Fatalf("GET %s returned error: %v", path, err)
+	}
+	resp.Request = r
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return resp
+}
+
+func expectStatus(t *testing.T, resp *http.Response, want int) {
+	t.Helper()
+	if resp.StatusCode != want {
+		t.Fatalf("GET %s: status = %d, want %d", resp.Request.URL.Path, resp.StatusCode, want)
+	}
+}
+
+func expectHeader(t *testing.T, resp *http.Response, name, want string) {
+	t.Helper()
+	if got := resp.Header.Get(name); got != want {
+		t.Errorf("GET %s: %s = %q, want %q", resp.Request.URL.Path, name, got, want)
+	}
+}
+
+func expectRetryAfter(t *testing.T, resp *http.Response) {
+	t.Helper()
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		t.Errorf("GET %s: Retry-After = %q, want a positive number of seconds", resp.Request.URL.Path, resp.Header.Get("Retry-After"))
+	}
+}
+
+func (s *adapterSuite) testLimit(t *testing.T) {
+	rt := s.serve(t, limiter.Config{}, AdapterConfig{})
+
+	for i := 0; i < adapterLimit; i++ {
+		resp := get(t, rt, "/ok")
+		expectStatus(t, resp, http.StatusOK)
+		expectHeader(t, resp, "X-RateLimit-Limit", strconv.Itoa(adapterLimit))
+		expectHeader(t, resp, "X-RateLimit-Remaining", strconv.Itoa(adapterLimit-i-1))
+		if resp.Header.Get("X-RateLimit-Reset") == "" {
+			t.Error("X-RateLimit-Reset is missing")
+		}
+	}
+
+	resp := get(t, rt, "/ok")
+	expectStatus(t, resp, http.StatusTooManyRequests)
+	expectHeader(t, resp, "X-RateLimit-Remaining", "0")
+	expectRetryAfter(t, resp)
+}
+
+func (s *adapterSuite) testKeyGenerator(t *testing.T) {
+	rt := s.serve(t, limiter.Config{}, AdapterConfig{KeyHeader: "X-Api-Key"})
+
+	for i := 0; i < adapterLimit; i++ {
+		expectStatus(t, get(t, rt, "/ok", "X-Api-Key", "alice"), http.StatusOK)
+	}
+	expectStatus(t, get(t, rt, "/ok", "X-Api-Key", "alice"), http.StatusTooManyRequests)
+	expectStatus(t, get(t, rt, "/ok", "X-Api-Key", "bob"), http.StatusOK)
+}
+
+// testKeysOutliveRequests catches keys pointing into a request buffer the
+// framework reuses, as zero-copy strings from fasthttp based frameworks
+// do: the second request overwrites the first key in place.
+func (s *adapterSuite) testKeysOutliveRequests(t *testing.T) {
+	l, rt := s.serveLimiter(t, limiter.Config{}, AdapterConfig{KeyHeader: "X-Api-Key"})
+
+	expectStatus(t, get(t, rt, "/ok", "X-Api-Key", "alice"), http.StatusOK)
+	expectStatus(t, get(t, rt, "/ok", "X-Api-Key", "carol"), http.StatusOK)
+
+	for _, key := range []string{"alice", "carol"} {
+		count, err := l.Store().Get(context.Background(), key)
+		if err != nil {
+			t.Fatalf("Store().Get(%q) returned error: %v", key, err)
+		}
+		if count != 1 {
+			t.Errorf("Store().Get(%q) = %d, want 1", key, count)
+		}
+	}
+}
+
+func (s *adapterSuite) testLimitReachedHandler(t *testing.T) {
+	rt := s.serve(t, limiter.Config{}, AdapterConfig{CustomHandlers: true})
+
+	for i := 0; i < adapterLimit; i++ {
+		expectStatus(t, get(t, rt, "/ok"), http.StatusOK)
+	}
+	resp := get(t, rt, "/ok")
+	expectStatus(t, resp, StatusLimitReached)
+	expectRetryAfter(t, resp)
+}
+
+// errStore fails every call.
+type errStore struct{}
+
+var errStoreDown = errors.New("store down")
+
+func (errStore) Take(context.Context, string, int, time.Duration, limiter.Algorithm) (bool, int, time.Time, error) {
+	return false, 0, time.Time{}, errStoreDown
+}
+
+func (errStore) Rollback(context.Context, string) error { return errStoreDown }
+
+func (errStore) Get(context.Context, string) (int, error) { return 0, errStoreDown }
+
+func (errStore) Set(context.Context, string, int, time.Duration) error { return errStoreDown }
+
+func (s *adapterSuite) testErrorHandler(t *testing.T) {
+	rt := s.serve(t, limiter.Config{Store: errStore{}}, AdapterConfig{})
+	expectStatus(t, get(t, rt, "/ok"), http.StatusInternalServerError)
+
+	rt = s.serve(t, limiter.Config{Store: errStore{}}, AdapterConfig{CustomHandlers: true})
+	expectStatus(t, get(t, rt, "/ok"), StatusError)
+}
+
+func (s *adapterSuite) testSkipSuccessful(t *testing.T) {
+	rt := s.serve(t, limiter.Config{}, AdapterConfig{Skipsuccessfull: true})
+
+	// Successful requests are given back, failed ones count.
+	for i := 0; i < adapterLimit+1; i++ {
+		expectStatus(t, get(t, rt, "/ok"), http.StatusOK)
+	}
+	for i := 0; i < adapterLimit; i++ {
+		expectStatus(t, get(t, rt, "/fail"), http.StatusInternalServerError)
+	}
+	expectStatus(t, get(t, rt, "/ok"), http.StatusTooManyRequests)
+}
+
+func (s *adapterSuite) testHeaders(t *testing.T) {
+	t.Run("IETF", func(t *testing.T) {
+		rt := s.serve(t, limiter.Config{}, AdapterConfig{Headers: limiter.HeadersIETF})
+
+		resp := get(t, rt, "/ok")
+		expectHeader(t, resp, "RateLimit-Limit", strconv.Itoa(adapterLimit))
+		expectHeader(t, resp, "RateLimit-Remaining", strconv.Itoa(adapterLimit-1))
+		expectHeader(t, resp, "RateLimit-Reset", "60")
+		expectHeader(t, resp, "X-RateLimit-Limit", "")
+	})
+
+	t.Run("None", func(t *testing.T) {
+		rt := s.serve(t, limiter.Config{}, AdapterConfig{Headers: limiter.HeadersNone})
+
+		for i := 0; i < adapterLimit; i++ {
+			resp := get(t, rt, "/ok")
+			expectHeader(t, resp, "X-RateLimit-Limit", "")
+			expectHeader(t, resp, "RateLimit-Limit", "")
+		}
+		resp := get(t, rt, "/ok")
+		expectStatus(t, resp, http.StatusTooManyRequests)
+		expectRetryAfter(t, resp)
+	})
+}
+
+func (s *adapterSuite) testHooks(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		decisions []limiter.Decision
+	)
+	record := func(d limiter.Decision) {
+		mu.Lock()
+		defer mu.Unlock()
+		decisions = append(decisions, d)
+	}
+	rt := s.serve(t, limiter.Config{Hooks: limiter.Hooks{OnAllowed: record, OnLimited: record}}, AdapterConfig{})
+
+	get(t, rt, "/ok", "User-Agent", "conformance")
+	mu.Lock()
+	defer mu.Unlock()
+	if len(decisions) != 1 {
+		t.Fatalf("hooks saw %d decisions, want 1", len(decisions))
+	}
+	req := decisions[0].Request
+	if req.Method != http.MethodGet || req.Path != "/ok" || req.UserAgent != "conformance" {
+		t.Errorf("Decision.Request = %+v, want GET /ok from user agent conformance", req)
+	}
+	if req.RemoteAddr == "" {
+		t.Error("Decision.Request.RemoteAddr is empty")
+	}
+}
@@ -0,0 +1,281 @@
+// Package limitertest provides helpers for testing code built on the limiter
+// package, most importantly conformance suites for custom Store
+// implementations and framework adapters.
+package limitertest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NarmadaWeb/limiter/v3"
+)
+
+// algorithms lists the algorithms every Store must support.
+var algorithms = []limiter.Algorithm{limiter.TokenBucket, limiter.SlidingWindow, limiter.FixedWindow}
+
+// Option customises RunStoreConformance.
+type Option func(*suite)
+
+// Clock is the time source the suite moves forward when it checks resets.
+// *limiter.FakeClock implements it.
+type Clock interface {
+	Now() time.Time
+	Advance(d time.Duration)
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) Advance(d time.Duration) { time.Sleep(d) }
+
+// WithClock makes the suite advance clock instead of sleeping. The stores
+// built by newStore must read the same clock.
+func WithClock(clock Clock) Option {
+	return func(s *suite) {
+		s.clock = clock
+	}
+}
+
+// WithWindow sets the window used by the timing checks. Defaults to 200ms,
+// which keeps the suite fast when it has to sleep.
+func WithWindow(window time.Duration) Option {
+	return func(s *suite) {
+		s.window = window
+	}
+}
+
+type suite struct {
+	newStore func() limiter.Store
+	clock    Clock
+	window   time.Duration
+}
+
+// RunStoreConformance checks that the stores built by newStore behave like
+// the built-in ones: the admit/deny sequence and remaining counts of every
+// algorithm, reset timing, rollback, concurrency safety and context
+// cancellation. newStore is called once per check and must return an empty
+// store. Run it with -race to catch data races.
+func RunStoreConformance(t *testing.T, newStore func() limiter.Store, opts ...Option) {
+	t.Helper()
+
+	s := &suite{
+		newStore: newStore,
+		clock:    realClock{},
+		window:   200 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	for _, algorithm := range algorithms {
+		t.Run(string(algorithm), func(t *testing.T) {
+			t.Run("AdmitDeny", func(t *testing.T) { s.testAdmitDeny(t, algorithm) })
+			t.Run("KeysAreIndependent", func(t *testing.T) { s.testKeysAreIndependent(t, algorithm) })
+			t.Run("Reset", func(t *testing.T) { s.testReset(t, algorithm) })
+			t.Run("Rollback", func(t *testing.T) { s.testRollback(t, algorithm) })
+			t.Run("Concurrency", func(t *testing.T) { s.testConcurrency(t, algorithm) })
+			t.Run("ContextCancellation", func(t *testing.T) { s.testContextCancellation(t, algorithm) })
+		})
+	}
+	t.Run("Get", s.testGet)
+}
+
+func (s *suite) store(t *testing.T) limiter.Store {
+	t.Helper()
+	store := s.newStore()
+	t.Cleanup(func() {
+		if closer, ok := store.(interface{ Close() error }); ok {
+			_ = closer.Close()
+		}
+	})
+	return store
+}
+
+// take calls Take and fails the test on error.
+func take(t *testing.T, store limiter.Store, key string, maxRequests int, window time.Duration, algorithm limiter.Algorithm) (bool, int, time.Time) {
+	t.Helper()
+	allowed, remaining, reset, err := store.Take(context.Background(), key, maxRequests, window, algorithm)
+	if err != nil {
+		t.Fatalf("Take(%q) returned error: %v", key, err)
+	}
+	return allowed, remaining, reset
+}
+
+func (s *suite) testAdmitDeny(t *testing.T, algorithm limiter.Algorithm) {
+	store := s.store(t)
+	const limit = 3
+
+	for i := 0; i < limit; i++ {
+		allowed, remaining, reset := take(t, store, "client", limit, time.Hour, algorithm)
+		if !allowed {
+			t.Fatalf("request %d was denied, want allowed", i+1)
+		}
+		if want := limit - i - 1; remaining != want {
+			t.Errorf("request %d: remaining = %d, want %d", i+1, remaining, want)
+		}
+		if reset.IsZero() {
+			t.Errorf("request %d: reset is zero", i+1)
+		}
+	}
+
+	allowed, remaining, _ := take(t, store, "client", limit, time.Hour, algorithm)
+	if allowed {
+		t.Fatalf("request %d was allowed, want denied", limit+1)
+	}
+	if remaining != 0 {
+		t.Errorf("denied request: remaining = %d, want 0", remaining)
+	}
+}
+
+func (s *suite) testKeysAreIndependent(t *testing.T, algorithm limiter.Algorithm) {
+	store := s.store(t)
+
+	take(t, store, "first", 1, time.Hour, algorithm)
+	if allowed, _, _ := take(t, store, "first", 1, time.Hour, algorithm); allowed {
+		t.Fatal("second request for first key was allowed, want denied")
+	}
+	if allowed, _, _ := take(t, store, "second", 1, time.Hour, algorithm); !allowed {
+		t.Fatal("first request for second key was denied, want allowed")
+	}
+}
+
+func (s *suite) testReset(t *testing.T, algorithm limiter.Algorithm) {
+	store := s.store(t)
+	const limit = 3
+
+	for i := 0; i < limit; i++ {
+		take(t, store, "client", limit, s.window, algorithm)
+	}
+	allowed, _, reset := take(t, store, "client", limit, s.window, algorithm)
+	if allowed {
+		t.Fatal("request over the limit was allowed")
+	}
+
+	// Every algorithm must admit a request once the reported reset has passed.
+	s.clock.Advance(reset.Sub(s.clock.Now()) + time.Millisecond)
+	allowed, _, _ = take(t, store, "client", limit, s.window, algorithm)
+	if !allowed {
+		t.Fatal("request after the reported reset was denied")
+	}
+
+	// A full window later the whole limit is available again.
+	s.clock.Advance(s.window + time.Millisecond)
+	for i := 0; i < limit; i++ {
+		if allowed, _, _ := take(t, store, "client", limit, s.window, algorithm); !allowed {
+			t.Fatalf("request %d after a full window was denied", i+1)
+		}
+	}
+}
+
+func (s *suite) testRollback(t *testing.T, algorithm limiter.Algorithm) {
+	store := s.store(t)
+	const limit = 2
+
+	for i := 0; i < limit; i++ {
+		take(t, store, "client", limit, time.Hour, algorithm)
+	}
+	if err := store.Rollback(context.Background(), "client"); err != nil {
+		t.Fatalf("Rollback returned error: %v", err)
+	}
+
+	if allowed, _, _ := take(t, store, "client", limit, time.Hour, algorithm); !allowed {
+		t.Fatal("request after rollback was denied")
+	}
+	if allowed, _, _ := take(t, store, "client", limit, time.Hour, algorithm); allowed {
+		t.Fatal("rollback returned more than one request")
+	}
+
+	// Rolling back a key that was never taken must not grant extra requests.
+	if err := store.Rollback(context.Background(), "unknown"); err != nil {
+		t.Fatalf("Rollback of unknown key returned error: %v", err)
+	}
+	for i := 0; i < limit; i++ {
+		take(t, store, "unknown", limit, time.Hour, algorithm)
+	}
+	if allowed, _, _ := take(t, store, "unknown", limit, time.Hour, algorithm); allowed {
+		t.Fatal("rollback of an unknown key granted an extra request")
+	}
+}
+
+func (s *suite) testConcurrency(t *testing.T, algorithm limiter.Algorithm) {
+	store := s.store(t)
+	const (
+		limit      = 50
+		goroutines = 20
+		perRoutine = 10
+	)
+
+	var (
+		admitted atomic.Int32
+		failures atomic.Int32
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perRoutine; j++ {
+				allowed, _, _, err := store.Take(context.Background(), "shared", limit, time.Hour, algorithm)
+				if err != nil {
+					failures.Add(1)
+					continue
+				}
+				if allowed {
+					admitted.Add(1)
+				}
+				// Touch other keys too so per-key locking is exercised.
+				_, _, _, _ = store.Take(context.Background(), fmt.Sprintf("own-%d", i), goroutines*perRoutine, time.Hour, algorithm)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n := failures.Load(); n > 0 {
+		t.Fatalf("%d concurrent Take calls failed", n)
+	}
+	if n := admitted.Load(); n != limit {
+		t.Fatalf("admitted %d concurrent requests, want exactly %d", n, limit)
+	}
+}
+
+func (s *suite) testContextCancellation(t *testing.T, algorithm limiter.Algorithm) {
+	store := s.store(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, _, _, err := store.Take(ctx, "client", 1, time.Hour, algorithm); err == nil {
+		t.Fatal("Take with a cancelled context returned no error")
+	}
+
+	// A cancelled call must not consume quota.
+	if allowed, _, _ := take(t, store, "client", 1, time.Hour, algorithm); !allowed {
+		t.Fatal("cancelled Take consumed quota")
+	}
+}
+
+func (s *suite) testGet(t *testing.T) {
+	store := s.store(t)
+
+	count, err := store.Get(context.Background(), "client")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Get of unknown key = %d, want 0", count)
+	}
+
+	take(t, store, "client", 5, time.Hour, limiter.FixedWindow)
+	take(t, store, "client", 5, time.Hour, limiter.FixedWindow)
+	count, err = store.Get(context.Background(), "client")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Get after two requests = %d, want 2", count)
+	}
+}
@@ -0,0 +1,208 @@
+// Package metrics exports Prometheus metrics for limiters.
+//
+// A single Collector can serve any number of limiters; they are told apart
+// by Config.Name. Labels only carry limiter-level values such as the name,
+// policy and store type, never client keys, so cardinality stays bounded.
+// The policy label follows UpdateConfig, so a reload that changes the limit
+// starts new series.
+//
+//	collector := metrics.NewCollector()
+//	prometheus.MustRegister(collector)
+//
+//	l, err := limiter.New(limiter.Config{
+//		Name:        "api",
+//		MaxRequests: 100,
+//		Window:      time.Minute,
+//		Algorithm:   limiter.SlidingWindow,
+//		Observer:    collector,
+//	})
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Options configures a Collector.
+type Options struct {
+	// Namespace prefixes every metric name. Defaults to "limiter".
+	Namespace string
+	// Buckets are the store latency histogram buckets in seconds. Defaults
+	// to 100µs through roughly 1.6s.
+	Buckets []float64
+}
+
+// Collector is both a limiter.Observer and a prometheus.Collector.
+type Collector struct {
+	requests    *prometheus.CounterVec
+	takeLatency *prometheus.HistogramVec
+	storeErrors *prometheus.CounterVec
+	rollbacks   *prometheus.CounterVec
+	failOpen    *prometheus.CounterVec
+	fallback    *prometheus.CounterVec
+	memoryKeys  *prometheus.Desc
+	memoryBytes *prometheus.Desc
+	evictions   *prometheus.Desc
+
+	mu     sync.Mutex
+	stores map[string]*limiter.MemoryStore
+}
+
+var (
+	_ limiter.Observer      = (*Collector)(nil)
+	_ limiter.StoreObserver = (*Collector)(nil)
+	_ prometheus.Collector  = (*Collector)(nil)
+)
+
+func NewCollector() *Collector {
+	return NewCollectorWithOptions(Options{})
+}
+
+func NewCollectorWithOptions(opts Options) *Collector {
+	if opts.Namespace == "" {
+		opts.Namespace = "limiter"
+	}
+	if len(opts.Buckets) == 0 {
+		opts.Buckets = prometheus.ExponentialBuckets(0.0001, 2, 15)
+	}
+
+	counter := func(name, help string, labels ...string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Name:      name,
+			Help:      help,
+		}, labels)
+	}
+	return &Collector{
+		requests: counter("requests_total",
+			"Requests seen by the limiter, by result (allowed or denied). Mode is enforced, dry_run or shadow.",
+			"limiter", "mode", "shadow", "policy", "result"),
+		takeLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: opts.Namespace,
+			Name:      "store_take_duration_seconds",
+			Help:      "Latency of Store.Take calls.",
+			Buckets:   opts.Buckets,
+		}, []string{"limiter", "store"}),
+		storeErrors: counter("store_errors_total",
+			"Store.Take calls that returned an error.", "limiter", "store"),
+		rollbacks: counter("rollbacks_total",
+			"Requests returned to the store, e.g. by Skipsuccessfull. Shadow limits are not counted.", "limiter"),
+		failOpen: counter("fail_open_total",
+			"Requests admitted by FailOpen while the store was failing.", "limiter"),
+		fallback: counter("fallback_total",
+			"Requests counted by the FallbackToLocal store.", "limiter"),
+		memoryKeys: prometheus.NewDesc(
+			prometheus.BuildFQName(opts.Namespace, "", "memory_store_keys"),
+			"Keys held by the limiter's MemoryStore.", []string{"limiter"}, nil),
+		memoryBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(opts.Namespace, "", "memory_store_bytes"),
+			"Estimated bytes held by the limiter's MemoryStore.", []string{"limiter"}, nil),
+		evictions: prometheus.NewDesc(
+			prometheus.BuildFQName(opts.Namespace, "", "memory_store_evictions_total"),
+			"Keys evicted from the limiter's MemoryStore to respect MaxKeys or MaxBytes.", []string{"limiter"}, nil),
+		stores: make(map[string]*limiter.MemoryStore),
+	}
+}
+
+func (c *Collector) StoreTake(ctx context.Context, info limiter.LimiterInfo) (context.Context, func(limiter.TakeResult)) {
+	start := time.Now()
+	return ctx, func(result limiter.TakeResult) {
+		c.takeLatency.WithLabelValues(info.Name, info.StoreType).Observe(time.Since(start).Seconds())
+		if result.Err != nil {
+			c.storeErrors.WithLabelValues(info.Name, info.StoreType).Inc()
+		}
+	}
+}
+
+func (c *Collector) Decision(_ context.Context, info limiter.LimiterInfo, allowed bool) {
+	result := "denied"
+	if allowed {
+		result = "allowed"
+	}
+	c.requests.WithLabelValues(info.Name, info.Mode(), info.Shadow, info.Policy, result).Inc()
+}
+
+func (c *Collector) PolicyApplied(_ context.Context, info limiter.LimiterInfo, policy limiter.FailurePolicy, _ error) {
+	switch policy {
+	case limiter.FailOpen:
+		c.failOpen.WithLabelValues(info.Name).Inc()
+	case limiter.FallbackToLocal:
+		c.fallback.WithLabelValues(info.Name).Inc()
+	}
+}
+
+func (c *Collector) Rollback(_ context.Context, info limiter.LimiterInfo, err error) {
+	if err == nil && info.Shadow == "" {
+		c.rollbacks.WithLabelValues(info.Name).Inc()
+	}
+}
+
+// ObserveStore starts exporting the key count, size and evictions of
+// MemoryStores.
+func (c *Collector) ObserveStore(info limiter.LimiterInfo, store limiter.Store) {
+	if memory, ok := memoryStore(store); ok {
+		c.mu.Lock()
+		c.stores[info.Name] = memory
+		c.mu.Unlock()
+	}
+}
+
+// ForgetStore stops exporting a store passed to ObserveStore.
+func (c *Collector) ForgetStore(info limiter.LimiterInfo, store limiter.Store) {
+	if memory, ok := memoryStore(store); ok {
+		c.mu.Lock()
+		if c.stores[info.Name] == memory {
+			delete(c.stores, info.Name)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// memoryStore finds the MemoryStore behind wrappers such as the
+// CircuitBreakerStore.
+func memoryStore(store limiter.Store) (*limiter.MemoryStore, bool) {
+	for {
+		switch s := store.(type) {
+		case *limiter.MemoryStore:
+			return s, true
+		case interface{ Unwrap() limiter.Store }:
+			store = s.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.takeLatency.Describe(ch)
+	c.storeErrors.Describe(ch)
+	c.rollbacks.Describe(ch)
+	c.failOpen.Describe(ch)
+	c.fallback.Describe(ch)
+	ch <- c.memoryKeys
+	ch <- c.memoryBytes
+	ch <- c.evictions
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.takeLatency.Collect(ch)
+	c.storeErrors.Collect(ch)
+	c.rollbacks.Collect(ch)
+	c.failOpen.Collect(ch)
+	c.fallback.Collect(ch)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, store := range c.stores {
+		stats := store.Stats()
+		ch <- prometheus.MustNewConstMetric(c.memoryKeys, prometheus.GaugeValue, float64(stats.Keys), name)
+		ch <- prometheus.MustNewConstMetric(c.memoryBytes, prometheus.GaugeValue, float64(stats.Bytes), name)
+		ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions), name)
+	}
+}
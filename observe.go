@@ -0,0 +1,169 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LimiterInfo describes a Limiter to an Observer. All fields but Algorithm
+// and Policy are fixed for the lifetime of the limiter; those two follow
+// UpdateConfig, so a metric labelled with them starts a new series on
+// every reload that changes them.
+type LimiterInfo struct {
+	Name      string
+	Algorithm Algorithm
+	// Policy is the RateLimit-Policy value, e.g. "100;w=60"
+	Policy string
+	// StoreType is "memory", "redis", "hybrid" or "custom"
+	StoreType     string
+	FailurePolicy FailurePolicy
+	// DryRun is set for a limiter that never rejects a request
+	DryRun bool
+	// Shadow names the ShadowLimit being evaluated, empty for the enforced
+	// limit
+	Shadow string
+}
+
+// Mode is "enforced", "dry_run" or "shadow".
+func (i LimiterInfo) Mode() string {
+	switch {
+	case i.Shadow != "":
+		return "shadow"
+	case i.DryRun:
+		return "dry_run"
+	default:
+		return "enforced"
+	}
+}
+
+// TakeResult is the outcome of a call to the store.
+type TakeResult struct {
+	Allowed   bool
+	Remaining int
+	Reset     time.Time
+	Err       error
+}
+
+// Observer is notified of what a Limiter does, e.g. to export metrics or
+// traces. Set it with Config.Observer. Its methods run on the request path,
+// so they must be cheap and safe for concurrent use.
+type Observer interface {
+	// StoreTake is called before every call to the primary store. The
+	// returned context is passed to the store and done is called with the
+	// outcome.
+	StoreTake(ctx context.Context, info LimiterInfo) (_ context.Context, done func(TakeResult))
+	// Decision is called once per request with the final verdict, after the
+	// FailurePolicy was applied.
+	Decision(ctx context.Context, info LimiterInfo, allowed bool)
+	// PolicyApplied is called for every request admitted by FailOpen or
+	// counted by the FallbackToLocal store. err is nil when the fallback is
+	// used because the store is still considered down.
+	PolicyApplied(ctx context.Context, info LimiterInfo, policy FailurePolicy, err error)
+	// Rollback is called when a request is returned, e.g. by Skipsuccessfull.
+	Rollback(ctx context.Context, info LimiterInfo, err error)
+}
+
+// StoreObserver is implemented by observers that also watch the store
+// itself, e.g. to export the MemoryStore key count. New calls ObserveStore
+// once with the primary store, which may be a CircuitBreakerStore, and
+// Close calls ForgetStore with the same store.
+type StoreObserver interface {
+	ObserveStore(info LimiterInfo, store Store)
+	ForgetStore(info LimiterInfo, store Store)
+}
+
+// MultiObserver fans every event out to all of observers in order.
+func MultiObserver(observers ...Observer) Observer {
+	return multiObserver(observers)
+}
+
+type multiObserver []Observer
+
+func (m multiObserver) StoreTake(ctx context.Context, info LimiterInfo) (context.Context, func(TakeResult)) {
+	dones := make([]func(TakeResult), len(m))
+	for i, o := range m {
+		ctx, dones[i] = o.StoreTake(ctx, info)
+	}
+	return ctx, func(result TakeResult) {
+		for i := len(dones) - 1; i >= 0; i-- {
+			dones[i](result)
+		}
+	}
+}
+
+func (m multiObserver) Decision(ctx context.Context, info LimiterInfo, allowed bool) {
+	for _, o := range m {
+		o.Decision(ctx, info, allowed)
+	}
+}
+
+func (m multiObserver) PolicyApplied(ctx context.Context, info LimiterInfo, policy FailurePolicy, err error) {
+	for _, o := range m {
+		o.PolicyApplied(ctx, info, policy, err)
+	}
+}
+
+func (m multiObserver) Rollback(ctx context.Context, info LimiterInfo, err error) {
+	for _, o := range m {
+		o.Rollback(ctx, info, err)
+	}
+}
+
+func (m multiObserver) ObserveStore(info LimiterInfo, store Store) {
+	for _, o := range m {
+		if so, ok := o.(StoreObserver); ok {
+			so.ObserveStore(info, store)
+		}
+	}
+}
+
+func (m multiObserver) ForgetStore(info LimiterInfo, store Store) {
+	for _, o := range m {
+		if so, ok := o.(StoreObserver); ok {
+			so.ForgetStore(info, store)
+		}
+	}
+}
+
+type nopObserver struct{}
+
+func (nopObserver) StoreTake(ctx context.Context, _ LimiterInfo) (context.Context, func(TakeResult)) {
+	return ctx, func(TakeResult) {}
+}
+
+func (nopObserver) Decision(context.Context, LimiterInfo, bool) {}
+
+func (nopObserver) PolicyApplied(context.Context, LimiterInfo, FailurePolicy, error) {}
+
+func (nopObserver) Rollback(context.Context, LimiterInfo, error) {}
+
+// newLimiterInfo describes the limiter built from config around store.
+func newLimiterInfo(config Config, store Store) LimiterInfo {
+	return LimiterInfo{
+		Name:          config.Name,
+		Algorithm:     config.Algorithm,
+		Policy:        fmt.Sprintf("%d;w=%d", config.MaxRequests, int64(config.Window.Seconds())),
+		StoreType:     storeType(store),
+		FailurePolicy: config.FailurePolicy,
+		DryRun:        config.DryRun,
+	}
+}
+
+// storeType names the kind of store, looking through a circuit breaker.
+// Stores from other packages, such as the redis module, name themselves
+// with a StoreType method.
+func storeType(store Store) string {
+	switch s := store.(type) {
+	case *CircuitBreakerStore:
+		return storeType(s.store)
+	case *MemoryStore:
+		return "memory"
+	case *HybridStore:
+		return "hybrid"
+	case interface{ StoreType() string }:
+		return s.StoreType()
+	default:
+		return "custom"
+	}
+}
@@ -0,0 +1,261 @@
+// Package otel instruments limiters with OpenTelemetry traces and metrics.
+//
+// Every call to the store gets a "limiter.Take" span. The adapters pass the
+// request context through, so the span nests under the HTTP server span
+// started by otelhttp, otelgin, otelecho or otelfiber. The metric
+// instruments mirror the ones exported by the metrics package.
+//
+//	observer, err := otel.NewObserver()
+//	if err != nil {
+//		return err
+//	}
+//	l, err := limiter.New(limiter.Config{
+//		Name:        "api",
+//		MaxRequests: 100,
+//		Window:      time.Minute,
+//		Algorithm:   limiter.SlidingWindow,
+//		Observer:    observer,
+//	})
+package otel
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/NarmadaWeb/limiter/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the tracer and the meter.
+const ScopeName = "github.com/NarmadaWeb/limiter/otel/v3"
+
+// Attribute keys set on spans and metrics.
+const (
+	NameKey      = attribute.Key("limiter.name")
+	AlgorithmKey = attribute.Key("limiter.algorithm")
+	PolicyKey    = attribute.Key("limiter.policy")
+	StoreKey     = attribute.Key("limiter.store")
+	AllowedKey   = attribute.Key("limiter.allowed")
+	RemainingKey = attribute.Key("limiter.remaining")
+	ResultKey    = attribute.Key("limiter.result")
+	ModeKey      = attribute.Key("limiter.mode")
+	ShadowKey    = attribute.Key("limiter.shadow")
+)
+
+// Options configures an Observer.
+type Options struct {
+	// TracerProvider defaults to the global tracer provider.
+	TracerProvider trace.TracerProvider
+	// MeterProvider defaults to the global meter provider.
+	MeterProvider metric.MeterProvider
+}
+
+// Observer is a limiter.Observer recording spans and metrics.
+type Observer struct {
+	tracer trace.Tracer
+
+	requests    metric.Int64Counter
+	takeLatency metric.Float64Histogram
+	storeErrors metric.Int64Counter
+	rollbacks   metric.Int64Counter
+	failOpen    metric.Int64Counter
+	fallback    metric.Int64Counter
+	memoryKeys  metric.Int64ObservableGauge
+	memoryBytes metric.Int64ObservableGauge
+	evictions   metric.Int64ObservableCounter
+
+	mu     sync.Mutex
+	stores map[string]*limiter.MemoryStore
+}
+
+var (
+	_ limiter.Observer      = (*Observer)(nil)
+	_ limiter.StoreObserver = (*Observer)(nil)
+)
+
+func NewObserver() (*Observer, error) {
+	return NewObserverWithOptions(Options{})
+}
+
+func NewObserverWithOptions(opts Options) (*Observer, error) {
+	if opts.TracerProvider == nil {
+		opts.TracerProvider = otel.GetTracerProvider()
+	}
+	if opts.MeterProvider == nil {
+		opts.MeterProvider = otel.GetMeterProvider()
+	}
+
+	o := &Observer{
+		tracer: opts.TracerProvider.Tracer(ScopeName),
+		stores: make(map[string]*limiter.MemoryStore),
+	}
+	meter := opts.MeterProvider.Meter(ScopeName)
+
+	var err error
+	counter := func(name, description string) metric.Int64Counter {
+		if err != nil {
+			return nil
+		}
+		var c metric.Int64Counter
+		c, err = meter.Int64Counter(name, metric.WithDescription(description))
+		return c
+	}
+	o.requests = counter("limiter.requests", "Requests seen by the limiter, by result (allowed or denied).")
+	o.storeErrors = counter("limiter.store.errors", "Store.Take calls that returned an error.")
+	o.rollbacks = counter("limiter.rollbacks", "Requests returned to the store, e.g. by Skipsuccessfull.")
+	o.failOpen = counter("limiter.fail_open", "Requests admitted by FailOpen while the store was failing.")
+	o.fallback = counter("limiter.fallback", "Requests counted by the FallbackToLocal store.")
+	if err != nil {
+		return nil, err
+	}
+
+	o.takeLatency, err = meter.Float64Histogram("limiter.store.take.duration",
+		metric.WithDescription("Latency of Store.Take calls."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+
+	o.memoryKeys, err = meter.Int64ObservableGauge("limiter.memory_store.keys",
+		metric.WithDescription("Keys held by the limiter's MemoryStore."))
+	if err != nil {
+		return nil, err
+	}
+	o.memoryBytes, err = meter.Int64ObservableGauge("limiter.memory_store.size",
+		metric.WithDescription("Estimated bytes held by the limiter's MemoryStore."), metric.WithUnit("By"))
+	if err != nil {
+		return nil, err
+	}
+	o.evictions, err = meter.Int64ObservableCounter("limiter.memory_store.evictions",
+		metric.WithDescription("Keys evicted from the limiter's MemoryStore to respect MaxKeys or MaxBytes."))
+	if err != nil {
+		return nil, err
+	}
+	_, err = meter.RegisterCallback(o.observeStores, o.memoryKeys, o.memoryBytes, o.evictions)
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *Observer) StoreTake(ctx context.Context, info limiter.LimiterInfo) (context.Context, func(limiter.TakeResult)) {
+	start := time.Now()
+	ctx, span := o.tracer.Start(ctx, "limiter.Take",
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(
+			NameKey.String(info.Name),
+			AlgorithmKey.String(string(info.Algorithm)),
+			PolicyKey.String(info.Policy),
+			StoreKey.String(info.StoreType),
+			ModeKey.String(info.Mode()),
+		))
+	if info.Shadow != "" {
+		span.SetAttributes(ShadowKey.String(info.Shadow))
+	}
+
+	return ctx, func(result limiter.TakeResult) {
+		attrs := metric.WithAttributes(NameKey.String(info.Name), StoreKey.String(info.StoreType))
+		o.takeLatency.Record(ctx, time.Since(start).Seconds(), attrs)
+
+		if result.Err != nil {
+			o.storeErrors.Add(ctx, 1, attrs)
+			span.RecordError(result.Err)
+			span.SetStatus(codes.Error, result.Err.Error())
+		} else {
+			span.SetAttributes(AllowedKey.Bool(result.Allowed), RemainingKey.Int(result.Remaining))
+		}
+		span.End()
+	}
+}
+
+func (o *Observer) Decision(ctx context.Context, info limiter.LimiterInfo, allowed bool) {
+	result := "denied"
+	if allowed {
+		result = "allowed"
+	}
+	o.requests.Add(ctx, 1, metric.WithAttributes(
+		NameKey.String(info.Name),
+		ModeKey.String(info.Mode()),
+		ShadowKey.String(info.Shadow),
+		PolicyKey.String(info.Policy),
+		ResultKey.String(result),
+	))
+}
+
+// PolicyApplied counts the activation and adds an event to the span in ctx,
+// usually the HTTP server span.
+func (o *Observer) PolicyApplied(ctx context.Context, info limiter.LimiterInfo, policy limiter.FailurePolicy, err error) {
+	attrs := metric.WithAttributes(NameKey.String(info.Name))
+	switch policy {
+	case limiter.FailOpen:
+		o.failOpen.Add(ctx, 1, attrs)
+	case limiter.FallbackToLocal:
+		o.fallback.Add(ctx, 1, attrs)
+	}
+
+	eventAttrs := []attribute.KeyValue{NameKey.String(info.Name)}
+	if err != nil {
+		eventAttrs = append(eventAttrs, attribute.String("error", err.Error()))
+	}
+	trace.SpanFromContext(ctx).AddEvent("limiter."+policy.String(), trace.WithAttributes(eventAttrs...))
+}
+
+func (o *Observer) Rollback(ctx context.Context, info limiter.LimiterInfo, err error) {
+	if err == nil && info.Shadow == "" {
+		o.rollbacks.Add(ctx, 1, metric.WithAttributes(NameKey.String(info.Name)))
+	}
+}
+
+// ObserveStore starts reporting the key count, size and evictions of
+// MemoryStores.
+func (o *Observer) ObserveStore(info limiter.LimiterInfo, store limiter.Store) {
+	if memory, ok := memoryStore(store); ok {
+		o.mu.Lock()
+		o.stores[info.Name] = memory
+		o.mu.Unlock()
+	}
+}
+
+// ForgetStore stops reporting a store passed to ObserveStore.
+func (o *Observer) ForgetStore(info limiter.LimiterInfo, store limiter.Store) {
+	if memory, ok := memoryStore(store); ok {
+		o.mu.Lock()
+		if o.stores[info.Name] == memory {
+			delete(o.stores, info.Name)
+		}
+		o.mu.Unlock()
+	}
+}
+
+func (o *Observer) observeStores(_ context.Context, observer metric.Observer) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for name, store := range o.stores {
+		stats := store.Stats()
+		attrs := metric.WithAttributes(NameKey.String(name))
+		observer.ObserveInt64(o.memoryKeys, int64(stats.Keys), attrs)
+		observer.ObserveInt64(o.memoryBytes, int64(stats.Bytes), attrs)
+		observer.ObserveInt64(o.evictions, int64(stats.Evictions), attrs)
+	}
+	return nil
+}
+
+// memoryStore finds the MemoryStore behind wrappers such as the
+// CircuitBreakerStore.
+func memoryStore(store limiter.Store) (*limiter.MemoryStore, bool) {
+	for {
+		switch s := store.(type) {
+		case *limiter.MemoryStore:
+			return s, true
+		case interface{ Unwrap() limiter.Store }:
+			store = s.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
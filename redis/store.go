@@ -0,0 +1,760 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/cespare/xxhash/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultPrefix is the prefix of every key written by a Store
+// unless StoreOptions.Prefix says otherwise.
+const DefaultPrefix = "rate_limit:"
+
+// KeyHash selects how client keys are hashed before they reach Redis.
+type KeyHash int
+
+const (
+	// KeyHashNone stores client keys verbatim.
+	KeyHashNone KeyHash = iota
+	// KeyHashSHA1 stores the hex SHA-1 of the client key.
+	KeyHashSHA1
+	// KeyHashXXHash stores the hex xxHash64 of the client key.
+	KeyHashXXHash
+)
+
+// StoreOptions tunes how a Store names its keys and evaluates its scripts.
+//
+// Keys are laid out as <Prefix>[<Namespace>:][<Name>:]<algorithm>:<key>.
+type StoreOptions struct {
+	// Prefix replaces DefaultPrefix.
+	Prefix string
+
+	// Namespace separates services sharing the same Redis.
+	Namespace string
+
+	// Name identifies the limiter owning the counters, so limiters with
+	// different configurations never share a key. Defaults to the
+	// Config.Name of the limiter using the store.
+	Name string
+
+	// KeyHash hashes client keys that are long or carry personal data.
+	KeyHash KeyHash
+
+	// Clock supplies the caller's time when UseServerTime is off. Defaults
+	// to the system clock.
+	Clock limiter.Clock
+
+	// UseServerTime makes every script read the clock with redis.call("TIME")
+	// instead of trusting the timestamp sent by the application server, so
+	// instances with skewed clocks still agree on window boundaries.
+	UseServerTime bool
+}
+
+type Store struct {
+	client        *redis.Client
+	prefix        string
+	keyHash       KeyHash
+	clock         limiter.Clock
+	useServerTime bool
+	named         bool
+}
+
+func NewStore(client *redis.Client) *Store {
+	return NewStoreWithOptions(client, StoreOptions{})
+}
+
+func NewStoreWithOptions(client *redis.Client, opts StoreOptions) *Store {
+	prefix := opts.Prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	if opts.Namespace != "" {
+		prefix += opts.Namespace + ":"
+	}
+	if opts.Name != "" {
+		prefix += opts.Name + ":"
+	}
+
+	if opts.Clock == nil {
+		opts.Clock = limiter.SystemClock{}
+	}
+
+	return &Store{
+		client:        client,
+		prefix:        prefix,
+		keyHash:       opts.KeyHash,
+		clock:         opts.Clock,
+		useServerTime: opts.UseServerTime,
+		named:         opts.Name != "",
+	}
+}
+
+// Named implements limiter.NamedStore: the returned store shares the
+// client and puts name in every key, unless StoreOptions.Name is set.
+func (r *Store) Named(name string) limiter.Store {
+	if r.named {
+		return r
+	}
+	named := *r
+	named.prefix += name + ":"
+	named.named = true
+	return &named
+}
+
+// Key returns the Redis key holding the state of key under algorithm.
+func (r *Store) Key(algorithm limiter.Algorithm, key string) string {
+	return r.prefix + string(algorithm) + ":" + r.hash(key)
+}
+
+// hash applies KeyHash to a client key.
+func (r *Store) hash(key string) string {
+	switch r.keyHash {
+	case KeyHashSHA1:
+		sum := sha1.Sum([]byte(key))
+		return hex.EncodeToString(sum[:])
+	case KeyHashXXHash:
+		return strconv.FormatUint(xxhash.Sum64String(key), 16)
+	}
+	return key
+}
+
+// Prefix returns the prefix of every key written by the store, including
+// the namespace and name.
+func (r *Store) Prefix() string {
+	return r.prefix
+}
+
+// OverrideKey returns the Redis key holding the limit of key set with
+// Override.
+func (r *Store) OverrideKey(key string) string {
+	return r.overrideKey(r.hash(key))
+}
+
+// LimitedKey returns the sorted set counting denials per key, hashed when
+// KeyHash is set.
+func (r *Store) LimitedKey() string {
+	return r.prefix + "limited"
+}
+
+// overrideKey holds the override of a hashed client key.
+func (r *Store) overrideKey(hashed string) string {
+	return r.prefix + "override:" + hashed
+}
+
+// scriptClock resolves the current time in milliseconds. ARGV[1] carries the
+// caller's clock and ARGV[2] is "1" when the Redis server clock must be used.
+const scriptClock = `
+	local now = tonumber(ARGV[1])
+	if ARGV[2] == "1" then
+		if redis.replicate_commands then
+			redis.replicate_commands()
+		end
+		local t = redis.call("TIME")
+		now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+	end
+`
+
+// MaxLimitedKeys caps the denied keys the sorted set behind TopLimited
+// holds. Past it, a newly denied key displaces the least denied one.
+const MaxLimitedKeys = 10000
+
+// scriptAdmin reads the override of the key from KEYS[2] and defines
+// denied, which counts a denial of ARGV[6] in the sorted set KEYS[3] and
+// trims it to MaxLimitedKeys members. An empty ARGV[6] is not counted.
+var scriptAdmin = `
+	local override = tonumber(redis.call("GET", KEYS[2]))
+	local function denied(window)
+		if ARGV[6] ~= "" then
+			redis.call("ZINCRBY", KEYS[3], 1, ARGV[6])
+			if redis.call("ZCARD", KEYS[3]) > ` + strconv.Itoa(MaxLimitedKeys) + ` then
+				-- Drop the least denied key other than this one.
+				local lowest = redis.call("ZRANGE", KEYS[3], 0, 1)
+				if lowest[1] == ARGV[6] then
+					redis.call("ZREM", KEYS[3], lowest[2])
+				else
+					redis.call("ZREM", KEYS[3], lowest[1])
+				end
+			end
+			if redis.call("PTTL", KEYS[3]) < window then
+				redis.call("PEXPIRE", KEYS[3], window)
+			end
+		end
+	end
+`
+
+// All take scripts work in milliseconds, grant up to ARGV[5] requests and
+// return {granted, remaining, resetMs}.
+var (
+	tokenBucketScript = redis.NewScript(scriptClock + scriptAdmin + `
+	local key = KEYS[1]
+	local window = tonumber(ARGV[3])
+	local maxRequests = override or tonumber(ARGV[4])
+	local n = tonumber(ARGV[5])
+
+	local bucket = redis.call("HMGET", key, "tokens", "lastUpdate", "max")
+	local tokens = tonumber(bucket[1])
+	local lastUpdate = tonumber(bucket[2])
+	if tokens == nil or lastUpdate == nil then
+		tokens = maxRequests
+		lastUpdate = now
+	elseif tonumber(bucket[3]) then
+		-- Keep the usage when the limit changes, e.g. with an override.
+		tokens = math.max(tokens + maxRequests - tonumber(bucket[3]), 0)
+	end
+
+	local fillRate = maxRequests / window
+	local timePassed = math.max(0, now - lastUpdate)
+	tokens = math.min(maxRequests, tokens + timePassed * fillRate)
+
+	if tokens < 1 then
+		denied(window)
+		return {0, 0, now + math.ceil((1 - tokens) / fillRate)}
+	end
+
+	local granted = math.min(n, math.floor(tokens))
+	tokens = tokens - granted
+	redis.call("HSET", key, "tokens", tokens, "lastUpdate", now, "max", maxRequests)
+	redis.call("PEXPIRE", key, window)
+	return {granted, math.floor(tokens), now + math.ceil((maxRequests - tokens) / fillRate)}
+	`)
+
+	slidingWindowScript = redis.NewScript(scriptClock + scriptAdmin + `
+	local key = KEYS[1]
+	local window = tonumber(ARGV[3])
+	local maxRequests = override or tonumber(ARGV[4])
+	local n = tonumber(ARGV[5])
+	local member = ARGV[7]
+
+	-- Remove old entries
+	redis.call("ZREMRANGEBYSCORE", key, "-inf", now - window)
+	local current = redis.call("ZCARD", key)
+
+	if current >= maxRequests then
+		denied(window)
+		local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+		if #oldest == 0 then
+			return {0, 0, now + window}
+		end
+		return {0, 0, tonumber(oldest[2]) + window}
+	end
+
+	-- Add new entries
+	local granted = math.min(n, maxRequests - current)
+	for i = 1, granted do
+		redis.call("ZADD", key, now, member .. ":" .. i)
+	end
+	redis.call("PEXPIRE", key, window)
+	return {granted, maxRequests - current - granted, now + window}
+	`)
+
+	fixedWindowScript = redis.NewScript(scriptClock + scriptAdmin + `
+	local key = KEYS[1]
+	local window = tonumber(ARGV[3])
+	local maxRequests = override or tonumber(ARGV[4])
+	local n = tonumber(ARGV[5])
+
+	local current = tonumber(redis.call("GET", key) or "0")
+
+	if current >= maxRequests then
+		denied(window)
+		local ttl = redis.call("PTTL", key)
+		if ttl < 0 then
+			ttl = window
+			redis.call("PEXPIRE", key, window)
+		end
+		return {0, 0, now + ttl}
+	end
+
+	local granted = math.min(n, maxRequests - current)
+	current = redis.call("INCRBY", key, granted)
+	local ttl = redis.call("PTTL", key)
+	if ttl < 0 then
+		ttl = window
+		redis.call("PEXPIRE", key, window)
+	end
+	return {granted, maxRequests - current, now + ttl}
+	`)
+)
+
+// rollbackScript undoes one request on the first of KEYS (fixed-window,
+// token-bucket, sliding-window) that exists, so a missing key is never
+// created with a negative count.
+var rollbackScript = redis.NewScript(`
+	if redis.call("EXISTS", KEYS[1]) == 1 then
+		if redis.call("DECR", KEYS[1]) <= 0 then
+			redis.call("DEL", KEYS[1])
+		end
+		return 1
+	end
+
+	if redis.call("EXISTS", KEYS[2]) == 1 then
+		local bucket = redis.call("HMGET", KEYS[2], "tokens", "max")
+		local tokens = (tonumber(bucket[1]) or 0) + 1
+		local maxRequests = tonumber(bucket[2])
+		if maxRequests ~= nil then
+			tokens = math.min(tokens, maxRequests)
+		end
+		redis.call("HSET", KEYS[2], "tokens", tokens)
+		return 1
+	end
+
+	if redis.call("EXISTS", KEYS[3]) == 1 then
+		redis.call("ZPOPMAX", KEYS[3])
+		return 1
+	end
+	return 0
+	`)
+
+// migrateScript moves the usage held in KEYS[1] under algorithm ARGV[6]
+// to KEYS[2] under algorithm ARGV[7], unless KEYS[2] already exists. The
+// token-bucket and sliding-window usage is read without refilling or
+// trimming, as the old window is unknown, which errs on the strict side.
+var migrateScript = redis.NewScript(scriptClock + `
+	local window = tonumber(ARGV[3])
+	local maxRequests = tonumber(ARGV[4])
+	local from = ARGV[6]
+	local to = ARGV[7]
+	local member = ARGV[8]
+
+	if redis.call("EXISTS", KEYS[2]) == 1 or redis.call("EXISTS", KEYS[1]) == 0 then
+		return 0
+	end
+
+	local used = 0
+	if from == "fixed-window" then
+		used = tonumber(redis.call("GET", KEYS[1])) or 0
+	elseif from == "token-bucket" then
+		local bucket = redis.call("HMGET", KEYS[1], "tokens", "max")
+		local tokens = tonumber(bucket[1]) or 0
+		used = (tonumber(bucket[2]) or maxRequests) - math.floor(tokens)
+	elseif from == "sliding-window" then
+		used = redis.call("ZCARD", KEYS[1])
+	end
+	used = math.min(used, maxRequests)
+	redis.call("DEL", KEYS[1])
+	if used <= 0 then
+		return 0
+	end
+
+	if to == "fixed-window" then
+		redis.call("SET", KEYS[2], used, "PX", window)
+	elseif to == "token-bucket" then
+		redis.call("HSET", KEYS[2], "tokens", maxRequests - used, "lastUpdate", now, "max", maxRequests)
+		redis.call("PEXPIRE", KEYS[2], window)
+	else
+		for i = 1, used do
+			redis.call("ZADD", KEYS[2], now, member .. ":" .. i)
+		end
+		redis.call("PEXPIRE", KEYS[2], window)
+	end
+	return 1
+	`)
+
+// statusScript reports {limit, used, resetMs, override, overrideMs, denied}
+// for KEYS[1] under algorithm ARGV[6], with the override in KEYS[2] and the
+// denials of ARGV[7] in KEYS[3]. Nothing is written.
+var statusScript = redis.NewScript(scriptClock + `
+	local window = tonumber(ARGV[3])
+	local maxRequests = tonumber(ARGV[4])
+	local algorithm = ARGV[6]
+
+	local override = tonumber(redis.call("GET", KEYS[2]))
+	local overrideMs = 0
+	if override then
+		maxRequests = override
+		overrideMs = now + math.max(redis.call("PTTL", KEYS[2]), 0)
+	else
+		override = 0
+	end
+
+	local used = 0
+	local reset = now
+	if algorithm == "fixed-window" then
+		used = tonumber(redis.call("GET", KEYS[1])) or 0
+		local ttl = redis.call("PTTL", KEYS[1])
+		if ttl > 0 then
+			reset = now + ttl
+		end
+	elseif algorithm == "token-bucket" then
+		local bucket = redis.call("HMGET", KEYS[1], "tokens", "lastUpdate", "max")
+		local tokens = tonumber(bucket[1])
+		local lastUpdate = tonumber(bucket[2])
+		if tokens ~= nil and lastUpdate ~= nil then
+			tokens = math.max(tokens + maxRequests - (tonumber(bucket[3]) or maxRequests), 0)
+			local fillRate = maxRequests / window
+			tokens = math.min(maxRequests, tokens + math.max(0, now - lastUpdate) * fillRate)
+			used = maxRequests - math.floor(tokens)
+			reset = now + math.ceil((maxRequests - tokens) / fillRate)
+		end
+	else
+		local oldest = redis.call("ZRANGEBYSCORE", KEYS[1], "(" .. (now - window), "+inf", "WITHSCORES")
+		used = #oldest / 2
+		if used > 0 then
+			reset = tonumber(oldest[2]) + window
+		end
+	end
+
+	local denials = tonumber(redis.call("ZSCORE", KEYS[3], ARGV[7])) or 0
+	return {maxRequests, used, reset, override, overrideMs, denials}
+	`)
+
+func (r *Store) Take(ctx context.Context, key string, maxRequests int, window time.Duration, algorithm limiter.Algorithm) (bool, int, time.Time, error) {
+	granted, remaining, reset, err := r.TakeN(ctx, key, 1, maxRequests, window, algorithm)
+	return granted == 1, remaining, reset, err
+}
+
+// TakeN grants up to n requests for key in a single round trip.
+func (r *Store) TakeN(ctx context.Context, key string, n, maxRequests int, window time.Duration, algorithm limiter.Algorithm) (int, int, time.Time, error) {
+	hashed := r.hash(key)
+	fullKey := r.prefix + string(algorithm) + ":" + hashed
+	now := r.clock.Now()
+	reset := now.Add(window)
+	// Denials of shadow limits are not reported by TopLimited.
+	member := hashed
+	if strings.HasPrefix(key, limiter.ShadowKeyPrefix) {
+		member = ""
+	}
+	args := append(r.scriptArgs(now, window, maxRequests, n), member)
+
+	var (
+		script  *redis.Script
+		keyType string
+	)
+	switch algorithm {
+	case limiter.TokenBucket:
+		script, keyType = tokenBucketScript, "hash"
+	case limiter.SlidingWindow:
+		// Members must be unique, otherwise two requests in the same millisecond
+		// would collapse into a single sorted set entry.
+		member := strconv.FormatInt(now.UnixNano(), 36) + "-" + strconv.FormatUint(rand.Uint64(), 36)
+		script, keyType = slidingWindowScript, "zset"
+		args = append(args, member)
+	case limiter.FixedWindow:
+		script, keyType = fixedWindowScript, "string"
+	default:
+		s, ok := limiter.LookupStrategy(algorithm)
+		if !ok {
+			return 0, 0, reset, fmt.Errorf("%w: %q", limiter.ErrInvalidAlgorithm, algorithm)
+		}
+		return limiter.TakeWithStrategy(ctx, r, s, key, n, maxRequests, window, now)
+	}
+
+	// Cleanup any existing key of wrong type
+	if err := r.ensureKeyType(ctx, fullKey, keyType); err != nil {
+		return 0, 0, reset, err
+	}
+
+	keys := []string{fullKey, r.overrideKey(hashed), r.LimitedKey()}
+	results, err := script.Run(ctx, r.client, keys, args...).Slice()
+	if err != nil {
+		return 0, 0, reset, redisError(string(algorithm)+" script", err)
+	}
+
+	return parseScriptResult(results, reset)
+}
+
+// scriptArgs builds the ARGV shared by every script: the caller's clock,
+// the server-time flag, the window, the limit and the requested count.
+func (r *Store) scriptArgs(now time.Time, window time.Duration, maxRequests, n int) []interface{} {
+	serverTime := "0"
+	if r.useServerTime {
+		serverTime = "1"
+	}
+	// Sub-millisecond windows cannot be expressed with PEXPIRE.
+	windowMs := max(window.Milliseconds(), 1)
+	return []interface{}{now.UnixMilli(), serverTime, windowMs, maxRequests, n}
+}
+
+// parseScriptResult converts a {granted, remaining, resetMs} script reply.
+func parseScriptResult(results []interface{}, fallbackReset time.Time) (int, int, time.Time, error) {
+	if len(results) != 3 {
+		return 0, 0, fallbackReset, fmt.Errorf("%w: unexpected script reply length %d", limiter.ErrStorage, len(results))
+	}
+	granted, ok1 := results[0].(int64)
+	remaining, ok2 := results[1].(int64)
+	resetMs, ok3 := results[2].(int64)
+	if !ok1 || !ok2 || !ok3 {
+		return 0, 0, fallbackReset, fmt.Errorf("%w: unexpected script reply %v", limiter.ErrStorage, results)
+	}
+
+	return int(granted), int(remaining), time.UnixMilli(resetMs), nil
+}
+
+// ensureKeyType checks and converts key type if needed
+func (r *Store) ensureKeyType(ctx context.Context, key, expectedType string) error {
+	actualType, err := r.client.Type(ctx, key).Result()
+	if err != nil {
+		return redisError("check key type", err)
+	}
+
+	// Key doesn't exist or is already correct type
+	if actualType == "none" || actualType == expectedType {
+		return nil
+	}
+
+	// Delete key if wrong type
+	if err := r.client.Del(ctx, key).Err(); err != nil {
+		return redisError("delete wrong type key", err)
+	}
+
+	return nil
+}
+
+// Rollback returns the most recent request for key to whichever algorithm
+// is tracking it.
+func (r *Store) Rollback(ctx context.Context, key string) error {
+	keys := []string{
+		r.Key(limiter.FixedWindow, key),
+		r.Key(limiter.TokenBucket, key),
+		r.Key(limiter.SlidingWindow, key),
+	}
+	done, err := rollbackScript.Run(ctx, r.client, keys).Int()
+	if err != nil {
+		return redisError("rollback script", err)
+	}
+	if done == 1 {
+		return nil
+	}
+
+	now := r.clock.Now()
+	for _, s := range limiter.Strategies() {
+		if err := limiter.RollbackWithStrategy(ctx, r, s, key, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Migrate implements limiter.Migrator for the built-in algorithms in one round trip.
+func (r *Store) Migrate(ctx context.Context, key string, from, to limiter.Algorithm, maxRequests int, window time.Duration) error {
+	if from == to || !from.Builtin() || !to.Builtin() {
+		return nil
+	}
+	now := r.clock.Now()
+	member := strconv.FormatInt(now.UnixNano(), 36) + "-" + strconv.FormatUint(rand.Uint64(), 36)
+	args := append(r.scriptArgs(now, window, maxRequests, 0), string(from), string(to), member)
+	keys := []string{r.Key(from, key), r.Key(to, key)}
+	if err := migrateScript.Run(ctx, r.client, keys, args...).Err(); err != nil {
+		return redisError("migrate script", err)
+	}
+	return nil
+}
+
+// maxStateRetries bounds how often UpdateState retries after a concurrent
+// write to the same key.
+const maxStateRetries = 100
+
+// UpdateState implements limiter.StateStore with an optimistic WATCH/MULTI
+// transaction, retried when another client wrote the key first.
+func (r *Store) UpdateState(ctx context.Context, key string, algorithm limiter.Algorithm, fn func(state []byte) ([]byte, time.Duration)) error {
+	fullKey := r.Key(algorithm, key)
+	update := func(tx *redis.Tx) error {
+		state, err := tx.Get(ctx, fullKey).Bytes()
+		if errors.Is(err, redis.Nil) {
+			state, err = nil, nil
+		}
+		if err != nil {
+			return err
+		}
+
+		next, ttl := fn(state)
+		if next == nil && state == nil || state == nil && ttl <= 0 {
+			return nil
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			switch {
+			case next == nil:
+				pipe.Del(ctx, fullKey)
+			case ttl > 0:
+				pipe.Set(ctx, fullKey, next, ttl)
+			default:
+				pipe.SetArgs(ctx, fullKey, next, redis.SetArgs{KeepTTL: true})
+			}
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < maxStateRetries; i++ {
+		err := r.client.Watch(ctx, update, fullKey)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, redis.TxFailedErr) {
+			return redisError("update state", err)
+		}
+	}
+	return fmt.Errorf("%w: too many concurrent updates of %s", limiter.ErrStorage, fullKey)
+}
+
+func (r *Store) Get(ctx context.Context, key string) (int, error) {
+	// Check all possible key types
+	if val, err := r.client.Get(ctx, r.Key(limiter.FixedWindow, key)).Int(); !errors.Is(err, redis.Nil) {
+		if err != nil {
+			return 0, redisError("get", err)
+		}
+		return val, nil
+	}
+
+	if val, err := r.client.HGet(ctx, r.Key(limiter.TokenBucket, key), "tokens").Float64(); !errors.Is(err, redis.Nil) {
+		if err != nil {
+			return 0, redisError("get", err)
+		}
+		return int(val), nil
+	}
+
+	val, err := r.client.ZCard(ctx, r.Key(limiter.SlidingWindow, key)).Result()
+	if err != nil {
+		return 0, redisError("get", err)
+	}
+	return int(val), nil
+}
+
+func (r *Store) Set(ctx context.Context, key string, value int, expiration time.Duration) error {
+	// Not implemented for multiple algorithms
+	return fmt.Errorf("%w: Set is not supported by Store: %w", limiter.ErrStorage, errors.ErrUnsupported)
+}
+
+// redisError wraps an error returned by Redis with ErrStorage, and with
+// ErrRedisConnection as well when the server could not be reached.
+func redisError(op string, err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, redis.ErrClosed) {
+		return fmt.Errorf("%w: %w: %s: %w", limiter.ErrStorage, limiter.ErrRedisConnection, op, err)
+	}
+	return fmt.Errorf("%w: %s: %w", limiter.ErrStorage, op, err)
+}
+
+// Status implements limiter.AdminStore for the built-in algorithms in one round trip.
+func (r *Store) Status(ctx context.Context, key string, maxRequests int, window time.Duration, algorithm limiter.Algorithm) (limiter.KeyStatus, error) {
+	status, err := r.status(ctx, r.hash(key), maxRequests, window, algorithm)
+	status.Key = key
+	return status, err
+}
+
+// status reads the state of a hashed client key.
+func (r *Store) status(ctx context.Context, hashed string, maxRequests int, window time.Duration, algorithm limiter.Algorithm) (limiter.KeyStatus, error) {
+	now := r.clock.Now()
+	status := limiter.KeyStatus{Key: hashed, Algorithm: algorithm, Limit: maxRequests, Remaining: maxRequests, Reset: now}
+	if !algorithm.Builtin() {
+		return status, fmt.Errorf("%w: status of %s keys: %w", limiter.ErrStorage, algorithm, errors.ErrUnsupported)
+	}
+
+	args := append(r.scriptArgs(now, window, maxRequests, 0), string(algorithm), hashed)
+	keys := []string{r.prefix + string(algorithm) + ":" + hashed, r.overrideKey(hashed), r.LimitedKey()}
+	results, err := statusScript.Run(ctx, r.client, keys, args...).Int64Slice()
+	if err != nil {
+		return status, redisError("status script", err)
+	}
+	if len(results) != 6 {
+		return status, fmt.Errorf("%w: unexpected status reply %v", limiter.ErrStorage, results)
+	}
+
+	status.Limit = int(results[0])
+	status.Count = int(results[1])
+	status.Remaining = max(status.Limit-status.Count, 0)
+	status.Reset = time.UnixMilli(results[2])
+	status.Denied = int(results[5])
+	if results[3] > 0 {
+		status.Override = int(results[3])
+		status.OverrideUntil = time.UnixMilli(results[4])
+	}
+	return status, nil
+}
+
+// Reset implements limiter.AdminStore. The denials of key are forgotten as well.
+func (r *Store) Reset(ctx context.Context, key string) error {
+	hashed := r.hash(key)
+	var keys []string
+	for _, algorithm := range limiter.Algorithms() {
+		keys = append(keys, r.prefix+string(algorithm)+":"+hashed)
+	}
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, keys...)
+		pipe.ZRem(ctx, r.LimitedKey(), hashed)
+		return nil
+	})
+	if err != nil {
+		return redisError("reset", err)
+	}
+	return nil
+}
+
+// Override implements limiter.AdminStore. The override is read by every instance
+// sharing the Redis, in the same round trip as Take.
+func (r *Store) Override(ctx context.Context, key string, maxRequests int, ttl time.Duration) error {
+	overrideKey := r.overrideKey(r.hash(key))
+	var err error
+	if maxRequests <= 0 || ttl <= 0 {
+		err = r.client.Del(ctx, overrideKey).Err()
+	} else {
+		err = r.client.Set(ctx, overrideKey, maxRequests, ttl).Err()
+	}
+	if err != nil {
+		return redisError("override", err)
+	}
+	return nil
+}
+
+// topLimitedPage is how many denied keys TopLimited reads per round trip.
+const topLimitedPage = 100
+
+// TopLimited implements limiter.AdminStore. Keys are reported hashed when KeyHash
+// is set. Denied keys whose state expired are dropped along the way. At most
+// MaxLimitedKeys denied keys are tracked: past that, a newly denied key
+// displaces the least denied one.
+func (r *Store) TopLimited(ctx context.Context, n, maxRequests int, window time.Duration, algorithm limiter.Algorithm) ([]limiter.KeyStatus, error) {
+	var limited []limiter.KeyStatus
+	for start := int64(0); len(limited) < n; start += topLimitedPage {
+		members, err := r.client.ZRevRange(ctx, r.LimitedKey(), start, start+topLimitedPage-1).Result()
+		if err != nil {
+			return nil, redisError("top limited", err)
+		}
+
+		var expired []interface{}
+		for _, hashed := range members {
+			status, err := r.status(ctx, hashed, maxRequests, window, algorithm)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case status.Count == 0:
+				expired = append(expired, hashed)
+			case status.Remaining == 0 && len(limited) < n:
+				limited = append(limited, status)
+			}
+		}
+		if len(expired) > 0 {
+			if err := r.client.ZRem(ctx, r.LimitedKey(), expired...).Err(); err != nil {
+				return nil, redisError("top limited", err)
+			}
+			start -= int64(len(expired))
+		}
+		if len(members) < topLimitedPage {
+			break
+		}
+	}
+	return limited, nil
+}
+
+func (r *Store) Close() error {
+	return r.client.Close()
+}
+
+// StoreType names the store in metrics and LimiterInfo.
+func (r *Store) StoreType() string {
+	return "redis"
+}
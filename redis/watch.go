@@ -0,0 +1,44 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/redis/go-redis/v9"
+)
+
+// Watch subscribes to channel and applies every message to the
+// configuration l has at the time, until ctx is done or l is closed. It
+// returns once the subscription is confirmed. Publish a change from any
+// instance or from redis-cli:
+//
+//	PUBLISH limiter:config '{"max_requests": 500}'
+func Watch(ctx context.Context, l *limiter.Limiter, client *redis.Client, channel string, opts limiter.WatchOptions) error {
+	pubsub := client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return redisError("subscribe", err)
+	}
+
+	go func() {
+		defer func() { _ = pubsub.Close() }()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-l.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				if err := l.ApplyUpdate([]byte(msg.Payload), opts); err != nil && opts.OnError != nil {
+					opts.OnError(fmt.Errorf("watch %s: %w", channel, err))
+				}
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,79 @@
+package limiter
+
+import (
+	"time"
+)
+
+// limiterState is the part of a Limiter replaced by UpdateConfig. It is
+// never modified once published.
+type limiterState struct {
+	config   Config
+	info     LimiterInfo
+	shadows  []shadow
+	fallback *MemoryStore
+	// migrateFrom is the algorithm keys are moved away from until
+	// migrateUntil, when every counter left under it has expired
+	migrateFrom  Algorithm
+	migrateUntil time.Time
+}
+
+// newState builds the state for config, reusing what prev already set up.
+func (l *Limiter) newState(config Config, prev *limiterState) *limiterState {
+	st := &limiterState{
+		config:  config,
+		info:    newLimiterInfo(config, l.store),
+		shadows: newShadows(config, l.store),
+	}
+	if prev != nil {
+		st.fallback = prev.fallback
+		st.migrateFrom, st.migrateUntil = prev.migrateFrom, prev.migrateUntil
+		if from := prev.config.Algorithm; from != config.Algorithm {
+			st.migrateFrom, st.migrateUntil = "", time.Time{}
+			if l.migrator != nil && from.Builtin() && config.Algorithm.Builtin() {
+				st.migrateFrom, st.migrateUntil = from, l.clock.Now().Add(prev.config.Window)
+			}
+		}
+	}
+	if st.fallback == nil && config.FailurePolicy == FallbackToLocal {
+		st.fallback = NewMemoryStoreWithOptions(MemoryStoreOptions{Clock: config.Clock})
+		st.fallback.StartJanitor(l.ctx)
+	}
+	return st
+}
+
+// Config returns the configuration currently in effect.
+func (l *Limiter) Config() Config {
+	return l.state.Load().config
+}
+
+// UpdateConfig validates cfg and swaps it in atomically: requests already
+// being decided finish with the old configuration, later ones use cfg.
+//
+// Name, Store, CircuitBreaker, Clock, Observer, RecoveryInterval and
+// OnStoreHealthChange are fixed by New; their values in cfg are ignored.
+// Start from Config() to change a single field.
+//
+// Counters carry over: a new MaxRequests or Window applies to the usage
+// already counted. When the Algorithm changes between built-in algorithms
+// and the store is a Migrator, each key's usage is moved to the new
+// algorithm on its first request during one old window.
+func (l *Limiter) UpdateConfig(cfg Config) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	prev := l.state.Load()
+	fixed := prev.config
+	cfg.Name = fixed.Name
+	cfg.Store = fixed.Store
+	cfg.CircuitBreaker = fixed.CircuitBreaker
+	cfg.Clock = fixed.Clock
+	cfg.Observer = fixed.Observer
+	cfg.RecoveryInterval = fixed.RecoveryInterval
+	cfg.OnStoreHealthChange = fixed.OnStoreHealthChange
+	if err := validateConfig(&cfg); err != nil {
+		return err
+	}
+
+	l.state.Store(l.newState(cfg, prev))
+	return nil
+}
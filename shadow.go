@@ -0,0 +1,111 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ShadowLimit is a candidate limit evaluated next to the enforced one
+// against the same traffic. It is counted in the limiter's store under its
+// own keys and reported to the Observer and Hooks, but never rejects a
+// request.
+type ShadowLimit struct {
+	// Name tells the shadow apart in metrics and decisions, required
+	Name        string
+	MaxRequests int
+	Window      time.Duration
+	// Algorithm defaults to the enforced one
+	Algorithm Algorithm
+}
+
+// ShadowKeyPrefix starts the store keys of every shadow limit. Stores
+// leave these keys out of the denials they report to AdminStore.
+const ShadowKeyPrefix = "shadow:"
+
+// shadow is a ShadowLimit ready to be evaluated.
+type shadow struct {
+	limit ShadowLimit
+	info  LimiterInfo
+	// prefix keeps the shadow counters apart from the enforced ones
+	prefix string
+}
+
+func newShadows(config Config, store Store) []shadow {
+	shadows := make([]shadow, len(config.Shadow))
+	for i, s := range config.Shadow {
+		if s.Algorithm == "" {
+			s.Algorithm = config.Algorithm
+		}
+		info := newLimiterInfo(Config{
+			Name:          config.Name,
+			MaxRequests:   s.MaxRequests,
+			Window:        s.Window,
+			Algorithm:     s.Algorithm,
+			FailurePolicy: config.FailurePolicy,
+		}, store)
+		info.Shadow = s.Name
+		shadows[i] = shadow{limit: s, info: info, prefix: ShadowKeyPrefix + s.Name + ":"}
+	}
+	return shadows
+}
+
+// validateShadows reports every invalid shadow field into errs.
+func validateShadows(shadows []ShadowLimit, errs *ConfigError) {
+	seen := make(map[string]bool, len(shadows))
+	for i, s := range shadows {
+		field := fmt.Sprintf("Shadow[%d].", i)
+		switch {
+		case s.Name == "":
+			errs.add(field+"Name", "must not be empty", nil)
+		case strings.Contains(s.Name, ":"):
+			errs.add(field+"Name", "must not contain ':'", nil)
+		case seen[s.Name]:
+			errs.add(field+"Name", fmt.Sprintf("duplicate shadow %q", s.Name), nil)
+		}
+		seen[s.Name] = true
+		if s.MaxRequests <= 0 {
+			errs.add(field+"MaxRequests", "must be positive", nil)
+		}
+		if s.Window <= 0 {
+			errs.add(field+"Window", "must be positive", nil)
+		}
+		if s.Algorithm != "" && !s.Algorithm.Valid() {
+			errs.add(field+"Algorithm", unknownAlgorithm(s.Algorithm), ErrInvalidAlgorithm)
+		}
+	}
+}
+
+// takeShadows counts the request against every shadow limit and reports
+// which of them granted it. Shadow errors reach OnError and the Observer
+// only; the FailurePolicy is not applied.
+func (l *Limiter) takeShadows(ctx context.Context, st *limiterState, key string, request func() RequestInfo) []bool {
+	if len(st.shadows) == 0 {
+		return nil
+	}
+	granted := make([]bool, len(st.shadows))
+	for i, s := range st.shadows {
+		storeCtx, done := l.observer.StoreTake(ctx, s.info)
+		allowed, remaining, reset, err := l.store.Take(storeCtx, s.prefix+key, s.limit.MaxRequests, s.limit.Window, s.limit.Algorithm)
+		done(TakeResult{Allowed: allowed, Remaining: remaining, Reset: reset, Err: err})
+
+		o := outcome{allowed: allowed, limit: s.limit.MaxRequests, remaining: remaining, reset: reset, err: err, storeErr: err, state: st}
+		if err == nil {
+			l.observer.Decision(ctx, s.info, allowed)
+		}
+		l.emit(s.info, key, request, o)
+		granted[i] = allowed
+	}
+	return granted
+}
+
+// rollbackShadows returns the request to every shadow limit that granted it.
+func (l *Limiter) rollbackShadows(ctx context.Context, st *limiterState, key string, granted []bool) {
+	for i, ok := range granted {
+		if ok {
+			s := st.shadows[i]
+			l.observer.Rollback(ctx, s.info, l.store.Rollback(ctx, s.prefix+key))
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package simulate
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NarmadaWeb/limiter/v3"
+)
+
+// Format is the syntax of an access log.
+type Format string
+
+const (
+	// Common is the Common Log Format. The Combined Log Format, which
+	// appends the referer and user agent, is read as well.
+	Common Format = "common"
+	// JSONLines is one JSON object per line.
+	JSONLines Format = "json"
+)
+
+// commonLogTime is the timestamp layout of the Common Log Format.
+const commonLogTime = "02/Jan/2006:15:04:05 -0700"
+
+// commonLog matches host ident user [time] "request" of a Common or
+// Combined Log Format line; the fields after the request are ignored.
+var commonLog = regexp.MustCompile(`^(\S+) \S+ (\S+) \[([^\]]+)\] "([^"]*)"`)
+
+// Event is one request of a log.
+type Event struct {
+	Time time.Time
+	Key  string
+}
+
+// ReadOptions configures Read.
+type ReadOptions struct {
+	// Format defaults to Common
+	Format Format
+	// Key selects the rate limit key. For Common logs it is "host"
+	// (default), "user", "path" or "request" (method and path). For JSON
+	// lines it names the field holding the key, "key" by default.
+	Key string
+	// TimeField names the JSON field holding the time, "timestamp" by
+	// default. It is an RFC 3339 string or a number of Unix seconds.
+	TimeField string
+	// SkipInvalid skips lines that cannot be parsed instead of failing
+	SkipInvalid bool
+}
+
+// Read parses every line of r and returns the events sorted by time.
+// Blank lines are ignored.
+func Read(r io.Reader, opts ReadOptions) ([]Event, error) {
+	var parse func(line string) (Event, error)
+	switch opts.Format {
+	case "", Common:
+		parse = func(line string) (Event, error) { return parseCommon(line, opts.Key) }
+	case JSONLines:
+		key, timeField := opts.Key, opts.TimeField
+		if key == "" {
+			key = "key"
+		}
+		if timeField == "" {
+			timeField = "timestamp"
+		}
+		parse = func(line string) (Event, error) { return parseJSON(line, key, timeField) }
+	default:
+		return nil, fmt.Errorf("%w: unknown log format %q", limiter.ErrInvalidConfig, opts.Format)
+	}
+
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		e, err := parse(line)
+		if err != nil {
+			if opts.SkipInvalid {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events, nil
+}
+
+func parseCommon(line, key string) (Event, error) {
+	m := commonLog.FindStringSubmatch(line)
+	if m == nil {
+		return Event{}, fmt.Errorf("not a common log line: %q", line)
+	}
+	t, err := time.Parse(commonLogTime, m[3])
+	if err != nil {
+		return Event{}, err
+	}
+
+	e := Event{Time: t}
+	method, target, _ := strings.Cut(m[4], " ")
+	target, _, _ = strings.Cut(target, " ")
+	path := target
+	if u, err := url.ParseRequestURI(target); err == nil {
+		path = u.Path
+	}
+	switch key {
+	case "", "host":
+		e.Key = m[1]
+	case "user":
+		e.Key = m[2]
+	case "path":
+		e.Key = path
+	case "request":
+		e.Key = method + " " + path
+	default:
+		return Event{}, fmt.Errorf("%w: unknown common log key %q, want host, user, path or request", limiter.ErrInvalidConfig, key)
+	}
+	return e, nil
+}
+
+func parseJSON(line, key, timeField string) (Event, error) {
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return Event{}, err
+	}
+
+	var e Event
+	switch v := fields[timeField].(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return Event{}, err
+		}
+		e.Time = t
+	case float64:
+		sec, frac := int64(v), v-float64(int64(v))
+		e.Time = time.Unix(sec, int64(frac*1e9)).UTC()
+	default:
+		return Event{}, fmt.Errorf("field %q is not a time", timeField)
+	}
+
+	switch v := fields[key].(type) {
+	case string:
+		e.Key = v
+	case float64:
+		e.Key = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return Event{}, fmt.Errorf("field %q is missing", key)
+	}
+	return e, nil
+}
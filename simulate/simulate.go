@@ -0,0 +1,179 @@
+// Package simulate replays recorded traffic through a limiter on a fake
+// clock, so limits and algorithms can be compared on real traffic before
+// they are deployed:
+//
+//	events, err := simulate.Read(file, simulate.ReadOptions{Format: simulate.Common})
+//	for _, algorithm := range []limiter.Algorithm{limiter.TokenBucket, limiter.SlidingWindow} {
+//		report, err := simulate.Run(events, limiter.Config{
+//			MaxRequests: 100,
+//			Window:      time.Minute,
+//			Algorithm:   algorithm,
+//		})
+//		fmt.Printf("%s: %.1f%% limited\n", algorithm, report.LimitedPercent())
+//	}
+//
+// Each run counts in a fresh MemoryStore, whatever store cfg names.
+package simulate
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/NarmadaWeb/limiter/v3"
+)
+
+// Report is the outcome of replaying events through one configuration.
+type Report struct {
+	Config     limiter.Config
+	Start, End time.Time
+	Requests   int
+	Denied     int
+	// Keys are sorted by denied requests, then by requests, most first
+	Keys []KeyReport
+}
+
+// KeyReport is the outcome for one key.
+type KeyReport struct {
+	Key      string
+	Requests int
+	Denied   int
+	// PeakDemand is the most requests the key sent within one Window
+	PeakDemand int
+	// PeakAdmitted is the most requests admitted within one Window. Above
+	// MaxRequests, the algorithm let a burst through, as a fixed window
+	// does across a window boundary.
+	PeakAdmitted int
+	// LongestBlock is the longest time from a denial to the next admitted
+	// request, or to the last denial when none was admitted after it
+	LongestBlock time.Duration
+}
+
+// LimitedPercent returns the percentage of requests denied.
+func (r *Report) LimitedPercent() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return 100 * float64(r.Denied) / float64(r.Requests)
+}
+
+// LimitedKeys returns how many keys had at least one request denied.
+func (r *Report) LimitedKeys() int {
+	n := 0
+	for _, k := range r.Keys {
+		if k.Denied > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// PeakAdmitted returns the highest PeakAdmitted of any key.
+func (r *Report) PeakAdmitted() int {
+	peak := 0
+	for _, k := range r.Keys {
+		peak = max(peak, k.PeakAdmitted)
+	}
+	return peak
+}
+
+// keyState tracks one key during a run.
+type keyState struct {
+	KeyReport
+	demand       window
+	admitted     window
+	blockedSince time.Time
+	lastDenied   time.Time
+}
+
+// window holds the times of the requests within the last Window, oldest
+// first.
+type window []time.Time
+
+// add records t and returns how many requests fall within d before it.
+func (w *window) add(t time.Time, d time.Duration) int {
+	cutoff := t.Add(-d)
+	i := 0
+	for i < len(*w) && !(*w)[i].After(cutoff) {
+		i++
+	}
+	*w = append((*w)[i:], t)
+	return len(*w)
+}
+
+// Run replays events, which must be sorted by time, through a limiter
+// built from cfg. cfg.Store and cfg.Clock are replaced by a MemoryStore on
+// a fake clock. DryRun and Shadow are ignored: the report shows what
+// enforcing cfg would do.
+func Run(events []Event, cfg limiter.Config) (*Report, error) {
+	report := &Report{Config: cfg}
+	if len(events) == 0 {
+		return report, nil
+	}
+
+	clock := limiter.NewFakeClock(events[0].Time)
+	cfg.Clock = clock
+	cfg.Store = limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{Clock: clock})
+	cfg.DryRun, cfg.Shadow = false, nil
+	l, err := limiter.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = l.Close() }()
+
+	ctx := context.Background()
+	keys := make(map[string]*keyState)
+	report.Start, report.End = events[0].Time, events[len(events)-1].Time
+	for _, e := range events {
+		if e.Time.After(clock.Now()) {
+			clock.Set(e.Time)
+		}
+		k, ok := keys[e.Key]
+		if !ok {
+			k = &keyState{KeyReport: KeyReport{Key: e.Key}}
+			keys[e.Key] = k
+		}
+
+		k.Requests++
+		k.PeakDemand = max(k.PeakDemand, k.demand.add(e.Time, cfg.Window))
+		var exceeded *limiter.LimitExceededError
+		switch err := l.Allow(ctx, e.Key); {
+		case err == nil:
+			k.PeakAdmitted = max(k.PeakAdmitted, k.admitted.add(e.Time, cfg.Window))
+			if !k.blockedSince.IsZero() {
+				k.LongestBlock = max(k.LongestBlock, e.Time.Sub(k.blockedSince))
+				k.blockedSince = time.Time{}
+			}
+		case errors.As(err, &exceeded):
+			k.Denied++
+			if k.blockedSince.IsZero() {
+				k.blockedSince = e.Time
+			}
+			k.lastDenied = e.Time
+		default:
+			return nil, err
+		}
+	}
+
+	report.Keys = make([]KeyReport, 0, len(keys))
+	for _, k := range keys {
+		if !k.blockedSince.IsZero() {
+			k.LongestBlock = max(k.LongestBlock, k.lastDenied.Sub(k.blockedSince))
+		}
+		report.Requests += k.Requests
+		report.Denied += k.Denied
+		report.Keys = append(report.Keys, k.KeyReport)
+	}
+	sort.Slice(report.Keys, func(i, j int) bool {
+		a, b := report.Keys[i], report.Keys[j]
+		if a.Denied != b.Denied {
+			return a.Denied > b.Denied
+		}
+		if a.Requests != b.Requests {
+			return a.Requests > b.Requests
+		}
+		return a.Key < b.Key
+	})
+	return report, nil
+}
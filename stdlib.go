@@ -2,11 +2,8 @@ package limiter
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
-	"strconv"
 	"strings"
-	"time"
 )
 
 type StdLibConfig struct {
@@ -14,6 +11,8 @@ type StdLibConfig struct {
 	LimitReachedHandler http.HandlerFunc
 	ErrorHandler        func(w http.ResponseWriter, r *http.Request, err error)
 	Skipsuccessfull     bool
+	// Headers selects the rate limit headers, HeadersXRateLimit by default
+	Headers HeaderMode
 }
 
 // StdLibMiddleware creates a standard net/http middleware.
@@ -67,18 +66,16 @@ func (l *Limiter) StdLibMiddleware(cfg StdLibConfig) func(http.Handler) http.Han
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			key := cfg.KeyGenerator(r)
 
-			allowed, remaining, reset, err := l.store.Take(r.Context(), key, l.config.MaxRequests, l.config.Window, l.config.Algorithm)
-			if err != nil {
-				cfg.ErrorHandler(w, r, err)
+			o := l.take(r.Context(), key, func() RequestInfo { return HTTPRequestInfo(r) })
+			if o.err != nil {
+				cfg.ErrorHandler(w, r, o.err)
 				return
 			}
 
-			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(l.config.MaxRequests))
-			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
-			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
-			w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", l.config.MaxRequests, int(time.Minute.Seconds())))
+			l.writeHeaders(w.Header().Set, cfg.Headers, o)
 
-			if !allowed {
+			if o.rejected {
+				w.Header().Set("Retry-After", l.retryAfter(o.reset))
 				cfg.LimitReachedHandler(w, r)
 				return
 			}
@@ -89,7 +86,7 @@ func (l *Limiter) StdLibMiddleware(cfg StdLibConfig) func(http.Handler) http.Han
 			next.ServeHTTP(ww, r)
 
 			if cfg.Skipsuccessfull && ww.code < http.StatusBadRequest {
-				_ = l.store.Rollback(r.Context(), key)
+				_ = l.rollback(r.Context(), key, o)
 			}
 		})
 	}
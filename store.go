@@ -2,104 +2,842 @@ package limiter
 
 import (
 	"context"
+	"hash/maphash"
+	"math"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Store defines the interface for limiter
 // Store have 4 values Take, Rollback, Get and Set
 type Store interface {
-	Take(ctx context.Context, key string, maxRequests int, window time.Duration, algorithm string) (bool, int, time.Time, error)
+	Take(ctx context.Context, key string, maxRequests int, window time.Duration, algorithm Algorithm) (bool, int, time.Time, error)
 	Rollback(ctx context.Context, key string) error
 	Get(ctx context.Context, key string) (int, error)
 	Set(ctx context.Context, key string, value int, expiration time.Duration) error
 }
 
+// BatchStore is implemented by stores that can grant several requests in a
+// single call. TakeN grants up to n requests and returns how many were
+// granted, the remaining count and the reset time.
+type BatchStore interface {
+	TakeN(ctx context.Context, key string, n, maxRequests int, window time.Duration, algorithm Algorithm) (int, int, time.Time, error)
+}
+
+// NamedStore is implemented by stores shared by several limiters that keep
+// the counters of each apart. New counts requests in Named(Config.Name)
+// when Config.Name is set.
+type NamedStore interface {
+	Named(name string) Store
+}
+
+// Migrator is implemented by stores that can carry the usage of key over
+// from one built-in algorithm to another, so changing Config.Algorithm with
+// UpdateConfig does not hand every client a fresh limit. Migrate does
+// nothing when key has no state under from or already has state under to.
+type Migrator interface {
+	Migrate(ctx context.Context, key string, from, to Algorithm, maxRequests int, window time.Duration) error
+}
+
+// AdminStore is implemented by stores that can be inspected and adjusted at
+// runtime, e.g. by the admin package. maxRequests, window and algorithm
+// are those of the limiter owning the keys.
+type AdminStore interface {
+	// Status reports the state of key. A key without state is reported
+	// with its full limit remaining.
+	Status(ctx context.Context, key string, maxRequests int, window time.Duration, algorithm Algorithm) (KeyStatus, error)
+	// Reset drops the state of key under every algorithm. Its override, if
+	// any, is kept.
+	Reset(ctx context.Context, key string) error
+	// Override replaces the limit of key with maxRequests for ttl. A
+	// maxRequests or ttl of zero removes the override.
+	Override(ctx context.Context, key string, maxRequests int, ttl time.Duration) error
+	// TopLimited returns up to n keys that have no requests remaining,
+	// the most denied first.
+	TopLimited(ctx context.Context, n, maxRequests int, window time.Duration, algorithm Algorithm) ([]KeyStatus, error)
+}
+
+// KeyStatus is the state of one key as reported by an AdminStore.
+type KeyStatus struct {
+	Key       string
+	Algorithm Algorithm
+	// Limit is the limit in force, the override when one is set
+	Limit     int
+	Count     int
+	Remaining int
+	// Reset is when the key regains its full limit
+	Reset time.Time
+	// Denied counts the requests denied since the key's state was created
+	Denied int
+	// Override is the temporary limit of the key, zero when there is none
+	Override      int
+	OverrideUntil time.Time
+}
+
+const (
+	defaultMemoryShards          = 64
+	defaultMemoryCleanupInterval = time.Minute
+	defaultEvictionSamples       = 5
+
+	// memoryEntryOverhead approximates the bytes a key costs beyond its own
+	// length: the map slot, the entry pointer and the MemoryEntries struct.
+	memoryEntryOverhead = 96
+)
+
+// EvictionPolicy chooses which key a full MemoryStore drops to make room.
+// Both policies look at a small random sample of keys in the full shard.
+type EvictionPolicy int
+
+const (
+	// EvictLRU drops the least recently used key of the sample.
+	EvictLRU EvictionPolicy = iota
+	// EvictMostRemaining drops the key with the most remaining quota, so
+	// clients close to their limit are the last to be forgotten. Ties are
+	// broken by least recent use.
+	EvictMostRemaining
+)
+
+// MemoryStoreOptions configures a MemoryStore.
+type MemoryStoreOptions struct {
+	// Shards is the number of independently locked maps keys are spread
+	// over. It is rounded up to a power of two. Defaults to 64.
+	Shards int
+	// CleanupInterval is how often the janitor deletes expired entries.
+	// Defaults to one minute.
+	CleanupInterval time.Duration
+
+	// MaxKeys caps the number of keys held, split evenly across shards.
+	// Zero means unlimited.
+	MaxKeys int
+	// MaxBytes caps the estimated memory used by keys, split evenly across
+	// shards. Zero means unlimited.
+	MaxBytes int
+	// Eviction selects the key dropped when a cap is reached
+	Eviction EvictionPolicy
+	// EvictionSamples is the number of keys sampled per eviction. Defaults to 5.
+	EvictionSamples int
+
+	// PressureThreshold is the fraction of MaxKeys or MaxBytes above which
+	// the store is under pressure, e.g. 0.9. Zero disables tightening.
+	PressureThreshold float64
+	// PressureScale multiplies the limit of keys taken while under
+	// pressure, e.g. 0.5 halves every limit until memory frees up.
+	PressureScale float64
+
+	// Clock defaults to the system clock
+	Clock Clock
+}
+
+// MemoryStoreStats is a snapshot of MemoryStore usage.
+type MemoryStoreStats struct {
+	Keys      int
+	Bytes     int
+	Evictions uint64
+}
+
+// MemoryStore keeps counters in process memory. Keys are hashed to one of
+// several shards so concurrent requests for different keys rarely contend,
+// and expired entries are deleted by a background janitor instead of on
+// every Take. With MaxKeys or MaxBytes set, a full shard evicts a key
+// before admitting a new one, so rotating client keys cannot exhaust memory.
 type MemoryStore struct {
-	mu      sync.Mutex
-	entries map[string]*MemoryEntries
+	shards          []*memoryShard
+	mask            uint64
+	seed            maphash.Seed
+	cleanupInterval time.Duration
+	clock           Clock
+
+	shardMaxKeys  int
+	shardMaxBytes int
+	eviction      EvictionPolicy
+	samples       int
+	pressure      float64
+	pressureScale float64
+	evictions     atomic.Uint64
+
+	janitorOnce sync.Once
+	closeOnce   sync.Once
+	done        chan struct{}
+}
+
+type memoryShard struct {
+	mu        sync.Mutex
+	entries   map[string]*MemoryEntries
+	overrides map[string]memoryOverride
+	bytes     int
 }
 
+// memoryOverride is a temporary limit set with Override.
+type memoryOverride struct {
+	limit int
+	until time.Time
+}
+
+// MemoryEntries holds the state of one key. Which fields are used depends
+// on the algorithm the key was last taken with.
 type MemoryEntries struct {
-	count     int
-	expiresAt time.Time
+	algorithm Algorithm
+	// fixed-window
+	count int
+	// token-bucket
+	tokens  float64
+	updated time.Time
+	// sliding-window, oldest first
+	hits []time.Time
+	// custom strategies
+	state []byte
+
+	limit      int
+	window     time.Duration
+	denied     int
+	size       int
+	expiresAt  time.Time
+	lastAccess time.Time
+}
+
+// used returns how much of the limit the entry has consumed at now.
+func (e *MemoryEntries) used(now time.Time) int {
+	switch e.algorithm {
+	case TokenBucket:
+		return e.limit - int(e.refilled(now))
+	case SlidingWindow:
+		return len(e.hits) - e.expiredHits(now)
+	case FixedWindow:
+		return e.count
+	default:
+		return 0
+	}
+}
+
+// refilled returns the tokens available at now.
+func (e *MemoryEntries) refilled(now time.Time) float64 {
+	elapsed := max(now.Sub(e.updated), 0)
+	rate := float64(e.limit) / float64(e.window)
+	return min(float64(e.limit), e.tokens+float64(elapsed)*rate)
+}
+
+// expiredHits returns how many leading hits fell out of the window.
+func (e *MemoryEntries) expiredHits(now time.Time) int {
+	cutoff := now.Add(-e.window)
+	i := 0
+	for i < len(e.hits) && !e.hits[i].After(cutoff) {
+		i++
+	}
+	return i
+}
+
+// entrySize estimates the bytes held for key and e.
+func entrySize(key string, e *MemoryEntries) int {
+	return len(key) + memoryEntryOverhead + 24*cap(e.hits) + cap(e.state)
+}
+
+// resize updates the byte accounting after e changed. It must be called
+// with s.mu held.
+func (s *memoryShard) resize(key string, e *MemoryEntries) {
+	if size := entrySize(key, e); size != e.size {
+		s.bytes += size - e.size
+		e.size = size
+	}
 }
 
 func NewMemoryStore() *MemoryStore {
-	return &MemoryStore{
-		entries: make(map[string]*MemoryEntries),
+	return NewMemoryStoreWithOptions(MemoryStoreOptions{})
+}
+
+func NewMemoryStoreWithOptions(opts MemoryStoreOptions) *MemoryStore {
+	shards := defaultMemoryShards
+	if opts.Shards > 0 {
+		shards = 1
+		for shards < opts.Shards {
+			shards <<= 1
+		}
+	}
+	if opts.CleanupInterval <= 0 {
+		opts.CleanupInterval = defaultMemoryCleanupInterval
 	}
+	if opts.EvictionSamples <= 0 {
+		opts.EvictionSamples = defaultEvictionSamples
+	}
+
+	m := &MemoryStore{
+		shards:          make([]*memoryShard, shards),
+		mask:            uint64(shards - 1),
+		seed:            maphash.MakeSeed(),
+		cleanupInterval: opts.CleanupInterval,
+		clock:           clockOrDefault(opts.Clock),
+		shardMaxKeys:    ceilDiv(opts.MaxKeys, shards),
+		shardMaxBytes:   ceilDiv(opts.MaxBytes, shards),
+		eviction:        opts.Eviction,
+		samples:         opts.EvictionSamples,
+		pressure:        opts.PressureThreshold,
+		pressureScale:   opts.PressureScale,
+		done:            make(chan struct{}),
+	}
+	for i := range m.shards {
+		m.shards[i] = &memoryShard{
+			entries:   make(map[string]*MemoryEntries),
+			overrides: make(map[string]memoryOverride),
+		}
+	}
+	return m
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+func (m *MemoryStore) shard(key string) *memoryShard {
+	return m.shards[maphash.String(m.seed, key)&m.mask]
 }
 
-func (m *MemoryStore) Take(ctx context.Context, key string, maxRequests int, window time.Duration, algorithm string) (bool, int, time.Time, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// StartJanitor deletes expired entries every CleanupInterval until ctx is
+// cancelled or the store is closed. Only the first call starts a janitor;
+// New starts it automatically with the Limiter's context.
+func (m *MemoryStore) StartJanitor(ctx context.Context) {
+	m.janitorOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(m.cleanupInterval)
+			defer ticker.Stop()
 
-	now := time.Now()
-	reset := now.Add(window)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-m.done:
+					return
+				case <-ticker.C:
+					m.deleteExpired(m.clock.Now())
+				}
+			}
+		}()
+	})
+}
 
-	// Cleanup expired entries
-	for k, v := range m.entries {
-		if now.After(v.expiresAt) {
-			delete(m.entries, k)
+// deleteExpired walks one shard at a time so Take is never blocked for
+// longer than a single shard scan.
+func (m *MemoryStore) deleteExpired(now time.Time) {
+	for _, shard := range m.shards {
+		shard.mu.Lock()
+		for k, v := range shard.entries {
+			if now.After(v.expiresAt) {
+				shard.remove(k)
+			}
+		}
+		for k, o := range shard.overrides {
+			if !now.Before(o.until) {
+				delete(shard.overrides, k)
+			}
 		}
+		shard.mu.Unlock()
 	}
+}
 
-	entry, exists := m.entries[key]
-	if !exists {
-		m.entries[key] = &MemoryEntries{
-			count:     1,
-			expiresAt: reset,
+// Len returns the number of keys currently held, including expired entries
+// the janitor has not deleted yet.
+func (m *MemoryStore) Len() int {
+	return m.Stats().Keys
+}
+
+// Stats returns the current key count, estimated bytes and total evictions.
+func (m *MemoryStore) Stats() MemoryStoreStats {
+	stats := MemoryStoreStats{Evictions: m.evictions.Load()}
+	for _, shard := range m.shards {
+		shard.mu.Lock()
+		stats.Keys += len(shard.entries)
+		stats.Bytes += shard.bytes
+		shard.mu.Unlock()
+	}
+	return stats
+}
+
+func (m *MemoryStore) Take(ctx context.Context, key string, maxRequests int, window time.Duration, algorithm Algorithm) (bool, int, time.Time, error) {
+	granted, remaining, reset, err := m.TakeN(ctx, key, 1, maxRequests, window, algorithm)
+	return granted == 1, remaining, reset, err
+}
+
+// TakeN grants up to n requests for key at once.
+func (m *MemoryStore) TakeN(ctx context.Context, key string, n, maxRequests int, window time.Duration, algorithm Algorithm) (int, int, time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, 0, m.clock.Now().Add(window), err
+	}
+	if !algorithm.Builtin() {
+		s, err := strategyFor(algorithm)
+		if err != nil {
+			return 0, 0, m.clock.Now().Add(window), err
+		}
+		return TakeWithStrategy(ctx, m, s, key, n, maxRequests, window, m.clock.Now())
+	}
+
+	shard := m.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	now := m.clock.Now()
+	maxRequests = m.tightened(shard, shard.limit(key, maxRequests, now))
+
+	entry, exists := shard.entries[key]
+	if !exists || now.After(entry.expiresAt) || entry.algorithm != algorithm {
+		entry = &MemoryEntries{
+			algorithm: algorithm,
+			tokens:    float64(maxRequests),
+			updated:   now,
+			expiresAt: now.Add(window),
+		}
+		m.insert(shard, key, entry, now)
+	} else if algorithm == TokenBucket && entry.limit != maxRequests {
+		// Keep the usage when the limit changes, e.g. with an override.
+		entry.tokens = max(entry.tokens+float64(maxRequests-entry.limit), 0)
+	}
+	entry.limit = maxRequests
+	entry.window = window
+	entry.lastAccess = now
+
+	var (
+		granted, remaining int
+		reset              time.Time
+	)
+	switch algorithm {
+	case TokenBucket:
+		granted, remaining, reset = entry.takeTokenBucket(now, n)
+	case SlidingWindow:
+		granted, remaining, reset = entry.takeSlidingWindow(now, n)
+	default: // FixedWindow
+		granted, remaining, reset = entry.takeFixedWindow(n)
+	}
+	if granted == 0 && !strings.HasPrefix(key, ShadowKeyPrefix) {
+		entry.denied++
+	}
+
+	shard.resize(key, entry)
+	return granted, remaining, reset, nil
+}
+
+func (e *MemoryEntries) takeFixedWindow(n int) (int, int, time.Time) {
+	if e.count >= e.limit {
+		return 0, 0, e.expiresAt
+	}
+
+	granted := min(n, e.limit-e.count)
+	e.count += granted
+	return granted, e.limit - e.count, e.expiresAt
+}
+
+func (e *MemoryEntries) takeTokenBucket(now time.Time, n int) (int, int, time.Time) {
+	tokens := e.refilled(now)
+	rate := float64(e.limit) / float64(e.window)
+
+	if tokens < 1 {
+		return 0, 0, now.Add(time.Duration(math.Ceil((1 - tokens) / rate)))
+	}
+
+	granted := min(n, int(tokens))
+	e.tokens = tokens - float64(granted)
+	e.updated = now
+	e.expiresAt = now.Add(e.window)
+	return granted, int(e.tokens), now.Add(time.Duration(math.Ceil((float64(e.limit) - e.tokens) / rate)))
+}
+
+func (e *MemoryEntries) takeSlidingWindow(now time.Time, n int) (int, int, time.Time) {
+	if expired := e.expiredHits(now); expired > 0 {
+		e.hits = append(e.hits[:0], e.hits[expired:]...)
+	}
+
+	if len(e.hits) >= e.limit {
+		if len(e.hits) == 0 {
+			return 0, 0, now.Add(e.window)
+		}
+		return 0, 0, e.hits[0].Add(e.window)
+	}
+
+	granted := min(n, e.limit-len(e.hits))
+	for i := 0; i < granted; i++ {
+		e.hits = append(e.hits, now)
+	}
+	e.expiresAt = now.Add(e.window)
+	return granted, e.limit - len(e.hits), now.Add(e.window)
+}
+
+// limit returns the override of key at now, or maxRequests. It must be
+// called with s.mu held.
+func (s *memoryShard) limit(key string, maxRequests int, now time.Time) int {
+	if o, ok := s.overrides[key]; ok {
+		if now.Before(o.until) {
+			return o.limit
+		}
+		delete(s.overrides, key)
+	}
+	return maxRequests
+}
+
+// tightened scales maxRequests down while shard is under memory pressure.
+// It must be called with shard.mu held.
+func (m *MemoryStore) tightened(shard *memoryShard, maxRequests int) int {
+	if m.pressure <= 0 || m.pressureScale <= 0 {
+		return maxRequests
+	}
+	underPressure := m.shardMaxKeys > 0 && float64(len(shard.entries)) >= m.pressure*float64(m.shardMaxKeys) ||
+		m.shardMaxBytes > 0 && float64(shard.bytes) >= m.pressure*float64(m.shardMaxBytes)
+	if !underPressure {
+		return maxRequests
+	}
+	return max(int(float64(maxRequests)*m.pressureScale), 1)
+}
+
+// insert stores entry under key, evicting other keys first when the shard is
+// full. It must be called with shard.mu held.
+func (m *MemoryStore) insert(shard *memoryShard, key string, entry *MemoryEntries, now time.Time) {
+	shard.remove(key)
+	size := entrySize(key, entry)
+	for len(shard.entries) > 0 &&
+		(m.shardMaxKeys > 0 && len(shard.entries) >= m.shardMaxKeys ||
+			m.shardMaxBytes > 0 && shard.bytes+size > m.shardMaxBytes) {
+		shard.remove(m.victim(shard, now))
+		m.evictions.Add(1)
+	}
+	entry.size = size
+	shard.entries[key] = entry
+	shard.bytes += size
+}
+
+// victim samples the shard and picks the key to evict. Expired keys are
+// always preferred. It must be called with shard.mu held.
+func (m *MemoryStore) victim(shard *memoryShard, now time.Time) string {
+	var (
+		victim string
+		chosen *MemoryEntries
+		seen   int
+	)
+	// Map iteration starts at a random position, which makes this a sample.
+	for k, e := range shard.entries {
+		if now.After(e.expiresAt) {
+			return k
+		}
+		if chosen == nil || m.evictBefore(e, chosen) {
+			victim, chosen = k, e
+		}
+		seen++
+		if seen >= m.samples {
+			break
 		}
-		return true, maxRequests - 1, reset, nil
 	}
+	return victim
+}
 
-	if entry.count >= maxRequests {
-		return false, 0, entry.expiresAt, nil
+func (m *MemoryStore) evictBefore(a, b *MemoryEntries) bool {
+	if m.eviction == EvictMostRemaining {
+		ra, rb := a.limit-a.usage(), b.limit-b.usage()
+		if ra != rb {
+			return ra > rb
+		}
 	}
+	return a.lastAccess.Before(b.lastAccess)
+}
 
-	entry.count++
-	return true, maxRequests - entry.count, entry.expiresAt, nil
+// usage approximates consumption for eviction decisions without a window.
+func (e *MemoryEntries) usage() int {
+	switch e.algorithm {
+	case TokenBucket:
+		return e.limit - int(e.tokens)
+	case SlidingWindow:
+		return len(e.hits)
+	default:
+		return e.count
+	}
+}
+
+// remove deletes key and its byte accounting. It must be called with s.mu held.
+func (s *memoryShard) remove(key string) {
+	if e, ok := s.entries[key]; ok {
+		delete(s.entries, key)
+		s.bytes -= e.size
+	}
 }
 
 func (m *MemoryStore) Rollback(ctx context.Context, key string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	shard := m.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	entry, exists := shard.entries[key]
+	if !exists {
+		return nil
+	}
 
-	if entry, exists := m.entries[key]; exists {
+	switch entry.algorithm {
+	case TokenBucket:
+		entry.tokens = min(entry.tokens+1, float64(entry.limit))
+	case SlidingWindow:
+		if len(entry.hits) > 0 {
+			entry.hits = entry.hits[:len(entry.hits)-1]
+		}
+	case FixedWindow:
 		entry.count--
 		if entry.count <= 0 {
-			delete(m.entries, key)
+			shard.remove(key)
+		}
+	default:
+		s, ok := LookupStrategy(entry.algorithm)
+		if !ok {
+			break
+		}
+		if entry.state = s.Rollback(entry.state, m.clock.Now()); entry.state == nil {
+			shard.remove(key)
+			break
 		}
+		shard.resize(key, entry)
+	}
+	return nil
+}
+
+// UpdateState implements StateStore.
+func (m *MemoryStore) UpdateState(ctx context.Context, key string, algorithm Algorithm, fn func(state []byte) ([]byte, time.Duration)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	shard := m.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	now := m.clock.Now()
+	var state []byte
+	entry, exists := shard.entries[key]
+	if exists && !now.After(entry.expiresAt) && entry.algorithm == algorithm {
+		state = entry.state
+	}
+
+	next, ttl := fn(state)
+	if next == nil {
+		if state != nil {
+			shard.remove(key)
+		}
+		return nil
+	}
+
+	if state == nil {
+		if ttl <= 0 {
+			return nil
+		}
+		entry = &MemoryEntries{algorithm: algorithm}
+		m.insert(shard, key, entry, now)
+	}
+	entry.state = next
+	entry.lastAccess = now
+	if ttl > 0 {
+		entry.expiresAt = now.Add(ttl)
 	}
+	shard.resize(key, entry)
 	return nil
 }
 
+// Migrate implements Migrator. The usage of key under from is replayed as
+// if it had been taken at once under to.
+func (m *MemoryStore) Migrate(ctx context.Context, key string, from, to Algorithm, maxRequests int, window time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if from == to || !slices.Contains(builtinAlgorithms, to) {
+		return nil
+	}
+
+	shard := m.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	now := m.clock.Now()
+	entry, exists := shard.entries[key]
+	if !exists || now.After(entry.expiresAt) || entry.algorithm != from {
+		return nil
+	}
+	used := min(entry.used(now), maxRequests)
+	if used <= 0 {
+		shard.remove(key)
+		return nil
+	}
+
+	next := &MemoryEntries{
+		algorithm:  to,
+		tokens:     float64(maxRequests),
+		updated:    now,
+		limit:      maxRequests,
+		window:     window,
+		expiresAt:  now.Add(window),
+		lastAccess: now,
+	}
+	switch to {
+	case TokenBucket:
+		next.takeTokenBucket(now, used)
+	case SlidingWindow:
+		next.takeSlidingWindow(now, used)
+	default: // FixedWindow
+		next.takeFixedWindow(used)
+	}
+	m.insert(shard, key, next, now)
+	return nil
+}
+
+// Get returns the number of requests key has used in its current window.
 func (m *MemoryStore) Get(ctx context.Context, key string) (int, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	shard := m.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
-	if entry, exists := m.entries[key]; exists {
-		return entry.count, nil
+	now := m.clock.Now()
+	if entry, exists := shard.entries[key]; exists && !now.After(entry.expiresAt) {
+		return entry.used(now), nil
 	}
 	return 0, nil
 }
 
+// Set stores a fixed-window count for key.
 func (m *MemoryStore) Set(ctx context.Context, key string, value int, expiration time.Duration) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	shard := m.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
-	m.entries[key] = &MemoryEntries{
-		count:     value,
-		expiresAt: time.Now().Add(expiration),
-	}
+	now := m.clock.Now()
+	m.insert(shard, key, &MemoryEntries{
+		algorithm:  FixedWindow,
+		count:      value,
+		window:     expiration,
+		expiresAt:  now.Add(expiration),
+		lastAccess: now,
+	}, now)
 	return nil
 }
 
+// Close stops the janitor and drops every entry.
 func (m *MemoryStore) Close() error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.closeOnce.Do(func() { close(m.done) })
+
+	for _, shard := range m.shards {
+		shard.mu.Lock()
+		shard.entries = make(map[string]*MemoryEntries)
+		shard.overrides = make(map[string]memoryOverride)
+		shard.bytes = 0
+		shard.mu.Unlock()
+	}
+	return nil
+}
+
+// Status implements AdminStore.
+func (m *MemoryStore) Status(ctx context.Context, key string, maxRequests int, window time.Duration, algorithm Algorithm) (KeyStatus, error) {
+	if err := ctx.Err(); err != nil {
+		return KeyStatus{}, err
+	}
+
+	shard := m.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	now := m.clock.Now()
+	status := KeyStatus{Key: key, Algorithm: algorithm, Reset: now}
+	if o, ok := shard.overrides[key]; ok && now.Before(o.until) {
+		status.Override, status.OverrideUntil = o.limit, o.until
+	}
+	status.Limit = shard.limit(key, maxRequests, now)
+	if entry, ok := shard.entries[key]; ok && !now.After(entry.expiresAt) && entry.algorithm == algorithm {
+		entry.status(&status, now)
+	} else {
+		status.Remaining = status.Limit
+	}
+	return status, nil
+}
+
+// status fills the usage of e at now into s. s.Limit defaults to the
+// limit e was last taken with.
+func (e *MemoryEntries) status(s *KeyStatus, now time.Time) {
+	if s.Limit == 0 {
+		s.Limit = e.limit
+	}
+	s.Count = e.used(now)
+	s.Remaining = max(s.Limit-s.Count, 0)
+	s.Denied = e.denied
+	switch e.algorithm {
+	case TokenBucket:
+		rate := float64(e.limit) / float64(e.window)
+		s.Reset = now.Add(time.Duration(math.Ceil((float64(e.limit) - e.refilled(now)) / rate)))
+	case SlidingWindow:
+		if expired := e.expiredHits(now); expired < len(e.hits) {
+			s.Reset = e.hits[expired].Add(e.window)
+		}
+	case FixedWindow:
+		s.Reset = e.expiresAt
+	}
+}
 
-	m.entries = make(map[string]*MemoryEntries)
+// Reset implements AdminStore.
+func (m *MemoryStore) Reset(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	shard := m.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	shard.remove(key)
 	return nil
 }
+
+// Override implements AdminStore.
+func (m *MemoryStore) Override(ctx context.Context, key string, maxRequests int, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	shard := m.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if maxRequests <= 0 || ttl <= 0 {
+		delete(shard.overrides, key)
+		return nil
+	}
+	shard.overrides[key] = memoryOverride{limit: maxRequests, until: m.clock.Now().Add(ttl)}
+	return nil
+}
+
+// TopLimited implements AdminStore by scanning every shard.
+func (m *MemoryStore) TopLimited(ctx context.Context, n, maxRequests int, window time.Duration, algorithm Algorithm) ([]KeyStatus, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	now := m.clock.Now()
+	var limited []KeyStatus
+	for _, shard := range m.shards {
+		shard.mu.Lock()
+		for key, entry := range shard.entries {
+			if entry.denied == 0 || now.After(entry.expiresAt) || entry.algorithm != algorithm || strings.HasPrefix(key, ShadowKeyPrefix) {
+				continue
+			}
+			status := KeyStatus{Key: key, Algorithm: algorithm, Reset: now}
+			if o, ok := shard.overrides[key]; ok && now.Before(o.until) {
+				status.Limit, status.Override, status.OverrideUntil = o.limit, o.limit, o.until
+			}
+			entry.status(&status, now)
+			if status.Count >= status.Limit {
+				limited = append(limited, status)
+			}
+		}
+		shard.mu.Unlock()
+	}
+
+	sort.Slice(limited, func(i, j int) bool {
+		if limited[i].Denied != limited[j].Denied {
+			return limited[i].Denied > limited[j].Denied
+		}
+		return limited[i].Key < limited[j].Key
+	})
+	if len(limited) > n {
+		limited = limited[:n]
+	}
+	return limited, nil
+}
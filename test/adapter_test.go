@@ -0,0 +1,129 @@
+package limiter_test
+
+import (
+	"net/http"
+	"testing"
+
+	limiterecho "github.com/NarmadaWeb/limiter/echo/v3"
+	limiterfiber "github.com/NarmadaWeb/limiter/fiber/v3"
+	limitergin "github.com/NarmadaWeb/limiter/gin/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/NarmadaWeb/limiter/v3/limitertest"
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gorilla/mux"
+	"github.com/labstack/echo/v4"
+)
+
+// stdLibAdapterConfig maps cfg onto the StdLib middleware, which also
+// serves Chi and Gorilla.
+func stdLibAdapterConfig(cfg limitertest.AdapterConfig) limiter.StdLibConfig {
+	stdCfg := limiter.StdLibConfig{Skipsuccessfull: cfg.Skipsuccessfull, Headers: cfg.Headers}
+	if cfg.KeyHeader != "" {
+		stdCfg.KeyGenerator = func(r *http.Request) string { return r.Header.Get(cfg.KeyHeader) }
+	}
+	if cfg.CustomHandlers {
+		stdCfg.LimitReachedHandler = func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(limitertest.StatusLimitReached)
+		}
+		stdCfg.ErrorHandler = func(w http.ResponseWriter, _ *http.Request, _ error) {
+			w.WriteHeader(limitertest.StatusError)
+		}
+	}
+	return stdCfg
+}
+
+func TestStdLibAdapterConformance(t *testing.T) {
+	limitertest.RunAdapterConformance(t, func(t *testing.T, l *limiter.Limiter, cfg limitertest.AdapterConfig) http.RoundTripper {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /ok", func(w http.ResponseWriter, _ *http.Request) {})
+		mux.HandleFunc("GET /fail", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+		return limitertest.HandlerTransport(l.StdLibMiddleware(stdLibAdapterConfig(cfg))(mux))
+	})
+}
+
+func TestGorillaAdapterConformance(t *testing.T) {
+	limitertest.RunAdapterConformance(t, func(t *testing.T, l *limiter.Limiter, cfg limitertest.AdapterConfig) http.RoundTripper {
+		r := mux.NewRouter()
+		r.Use(l.StdLibMiddleware(stdLibAdapterConfig(cfg)))
+		r.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {}).Methods(http.MethodGet)
+		r.HandleFunc("/fail", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}).Methods(http.MethodGet)
+		return limitertest.HandlerTransport(r)
+	})
+}
+
+func TestGinAdapterConformance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limitertest.RunAdapterConformance(t, func(t *testing.T, l *limiter.Limiter, cfg limitertest.AdapterConfig) http.RoundTripper {
+		ginCfg := limitergin.Config{Skipsuccessfull: cfg.Skipsuccessfull, Headers: cfg.Headers}
+		if cfg.KeyHeader != "" {
+			ginCfg.KeyGenerator = func(c *gin.Context) string { return c.GetHeader(cfg.KeyHeader) }
+		}
+		if cfg.CustomHandlers {
+			ginCfg.LimitReachedHandler = func(c *gin.Context) {
+				c.AbortWithStatus(limitertest.StatusLimitReached)
+			}
+			ginCfg.ErrorHandler = func(c *gin.Context, _ error) {
+				c.AbortWithStatus(limitertest.StatusError)
+			}
+		}
+
+		r := gin.New()
+		r.Use(limitergin.New(l, ginCfg))
+		r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+		r.GET("/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+		return limitertest.HandlerTransport(r)
+	})
+}
+
+func TestEchoAdapterConformance(t *testing.T) {
+	limitertest.RunAdapterConformance(t, func(t *testing.T, l *limiter.Limiter, cfg limitertest.AdapterConfig) http.RoundTripper {
+		echoCfg := limiterecho.Config{Skipsuccessfull: cfg.Skipsuccessfull, Headers: cfg.Headers}
+		if cfg.KeyHeader != "" {
+			echoCfg.KeyGenerator = func(c echo.Context) string { return c.Request().Header.Get(cfg.KeyHeader) }
+		}
+		if cfg.CustomHandlers {
+			echoCfg.LimitReachedHandler = func(c echo.Context) error {
+				return c.NoContent(limitertest.StatusLimitReached)
+			}
+			echoCfg.ErrorHandler = func(c echo.Context, _ error) error {
+				return c.NoContent(limitertest.StatusError)
+			}
+		}
+
+		e := echo.New()
+		e.Use(limiterecho.New(l, echoCfg))
+		e.GET("/ok", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+		e.GET("/fail", func(c echo.Context) error { return c.NoContent(http.StatusInternalServerError) })
+		return limitertest.HandlerTransport(e)
+	})
+}
+
+func TestFiberAdapterConformance(t *testing.T) {
+	limitertest.RunAdapterConformance(t, func(t *testing.T, l *limiter.Limiter, cfg limitertest.AdapterConfig) http.RoundTripper {
+		fiberCfg := limiterfiber.Config{Skipsuccessfull: cfg.Skipsuccessfull, Headers: cfg.Headers}
+		if cfg.KeyHeader != "" {
+			fiberCfg.KeyGenerator = func(c *fiber.Ctx) string { return c.Get(cfg.KeyHeader) }
+		}
+		if cfg.CustomHandlers {
+			fiberCfg.LimitReachedHandler = func(c *fiber.Ctx) error {
+				return c.SendStatus(limitertest.StatusLimitReached)
+			}
+			fiberCfg.ErrorHandler = func(c *fiber.Ctx, _ error) error {
+				return c.SendStatus(limitertest.StatusError)
+			}
+		}
+
+		app := fiber.New()
+		app.Use(limiterfiber.New(l, fiberCfg))
+		app.Get("/ok", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+		app.Get("/fail", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusInternalServerError) })
+		return limitertest.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+			return app.Test(r, -1)
+		})
+	})
+}
@@ -0,0 +1,249 @@
+package limiter_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	redisstore "github.com/NarmadaWeb/limiter/redis/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/NarmadaWeb/limiter/v3/admin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminStore(t *testing.T) {
+	stores := map[string]func(t *testing.T, clock limiter.Clock) limiter.AdminStore{
+		"memory": func(_ *testing.T, clock limiter.Clock) limiter.AdminStore {
+			return limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{Clock: clock})
+		},
+		"redis": func(t *testing.T, clock limiter.Clock) limiter.AdminStore {
+			mr, client := newTestRedis(t)
+			mr.SetTime(clockStart)
+			return redisstore.NewStoreWithOptions(client, redisstore.StoreOptions{Clock: clock})
+		},
+	}
+	for name, newStore := range stores {
+		for _, algorithm := range []limiter.Algorithm{limiter.TokenBucket, limiter.SlidingWindow, limiter.FixedWindow} {
+			t.Run(name+"/"+string(algorithm), func(t *testing.T) {
+				clock := limiter.NewFakeClock(clockStart)
+				store := newStore(t, clock)
+				ctx := context.Background()
+				take := func(key string) bool {
+					allowed, _, _, err := store.(limiter.Store).Take(ctx, key, 2, time.Minute, algorithm)
+					require.NoError(t, err)
+					return allowed
+				}
+
+				status, err := store.Status(ctx, "alice", 2, time.Minute, algorithm)
+				require.NoError(t, err)
+				assert.Equal(t, 2, status.Limit)
+				assert.Equal(t, 2, status.Remaining)
+				assert.Zero(t, status.Count)
+				assert.WithinDuration(t, clockStart, status.Reset, 0)
+
+				assert.True(t, take("alice"))
+				assert.True(t, take("alice"))
+				assert.False(t, take("alice"))
+				assert.False(t, take("alice"))
+				assert.True(t, take("bob"))
+				assert.True(t, take("bob"))
+				assert.False(t, take("bob"))
+				assert.True(t, take("carol"))
+				_, _, _, err = store.(limiter.Store).Take(ctx, "shadow:strict:dave", 1, time.Minute, algorithm)
+				require.NoError(t, err)
+				_, _, _, err = store.(limiter.Store).Take(ctx, "shadow:strict:dave", 1, time.Minute, algorithm)
+				require.NoError(t, err)
+
+				status, err = store.Status(ctx, "alice", 2, time.Minute, algorithm)
+				require.NoError(t, err)
+				assert.Equal(t, 2, status.Count)
+				assert.Equal(t, 0, status.Remaining)
+				assert.Equal(t, 2, status.Denied)
+				assert.WithinDuration(t, clockStart.Add(time.Minute), status.Reset, 0)
+
+				top, err := store.TopLimited(ctx, 10, 2, time.Minute, algorithm)
+				require.NoError(t, err)
+				require.Len(t, top, 2, "carol has requests left and shadow keys are skipped")
+				assert.Equal(t, "alice", top[0].Key)
+				assert.Equal(t, "bob", top[1].Key)
+				top, err = store.TopLimited(ctx, 1, 2, time.Minute, algorithm)
+				require.NoError(t, err)
+				require.Len(t, top, 1)
+				assert.Equal(t, "alice", top[0].Key)
+
+				// An override raises the limit of one key until it expires.
+				require.NoError(t, store.Override(ctx, "alice", 3, 30*time.Second))
+				status, err = store.Status(ctx, "alice", 2, time.Minute, algorithm)
+				require.NoError(t, err)
+				assert.Equal(t, 3, status.Limit)
+				assert.Equal(t, 3, status.Override)
+				assert.WithinDuration(t, clockStart.Add(30*time.Second), status.OverrideUntil, 0)
+				assert.True(t, take("alice"))
+				assert.False(t, take("alice"))
+				assert.False(t, take("bob"))
+
+				require.NoError(t, store.Reset(ctx, "alice"))
+				status, err = store.Status(ctx, "alice", 2, time.Minute, algorithm)
+				require.NoError(t, err)
+				assert.Equal(t, 0, status.Count)
+				assert.Equal(t, 0, status.Denied)
+				assert.Equal(t, 3, status.Remaining, "the override outlives a reset")
+
+				require.NoError(t, store.Override(ctx, "alice", 0, 0))
+				status, err = store.Status(ctx, "alice", 2, time.Minute, algorithm)
+				require.NoError(t, err)
+				assert.Equal(t, 2, status.Limit)
+				assert.Zero(t, status.Override)
+
+				top, err = store.TopLimited(ctx, 10, 2, time.Minute, algorithm)
+				require.NoError(t, err)
+				require.Len(t, top, 1)
+				assert.Equal(t, "bob", top[0].Key)
+			})
+		}
+	}
+}
+
+func TestRedisStoreCapsLimitedKeys(t *testing.T) {
+	_, client := newTestRedis(t)
+	ctx := context.Background()
+	store := redisstore.NewStore(client)
+
+	members := make([]redis.Z, redisstore.MaxLimitedKeys)
+	for i := range members {
+		members[i] = redis.Z{Score: 2, Member: "client-" + strconv.Itoa(i)}
+	}
+	require.NoError(t, client.ZAdd(ctx, store.LimitedKey(), members...).Err())
+
+	for i := 0; i < 4; i++ {
+		_, _, _, err := store.Take(ctx, "alice", 1, time.Minute, limiter.FixedWindow)
+		require.NoError(t, err)
+	}
+
+	count, err := client.ZCard(ctx, store.LimitedKey()).Result()
+	require.NoError(t, err)
+	assert.EqualValues(t, redisstore.MaxLimitedKeys, count)
+	score, err := client.ZScore(ctx, store.LimitedKey(), "alice").Result()
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, score)
+}
+
+func TestRedisStoreOverrideIsShared(t *testing.T) {
+	_, client := newTestRedis(t)
+	ctx := context.Background()
+	a := redisstore.NewStoreWithOptions(client, redisstore.StoreOptions{KeyHash: redisstore.KeyHashSHA1})
+	b := redisstore.NewStoreWithOptions(client, redisstore.StoreOptions{KeyHash: redisstore.KeyHashSHA1})
+
+	require.NoError(t, a.Override(ctx, "alice", 3, time.Minute))
+	for i := 0; i < 3; i++ {
+		allowed, _, _, err := b.Take(ctx, "alice", 1, time.Minute, limiter.FixedWindow)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, _, _, err := b.Take(ctx, "alice", 1, time.Minute, limiter.FixedWindow)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	// Hashed keys are reported as stored.
+	top, err := a.TopLimited(ctx, 10, 1, time.Minute, limiter.FixedWindow)
+	require.NoError(t, err)
+	require.Len(t, top, 1)
+	assert.Len(t, top[0].Key, 40)
+	assert.Equal(t, 3, top[0].Count)
+}
+
+func TestAdminHandler(t *testing.T) {
+	clock := limiter.NewFakeClock(clockStart)
+	l, err := limiter.New(limiter.Config{
+		MaxRequests: 2,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+		Clock:       clock,
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	handler := admin.NewHandler(l, func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer secret"
+	})
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/keys/alice", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	assert.Equal(t, 2, allowN(l, "10.0.0.1/api", 3))
+
+	w = do(http.MethodGet, "/keys/10.0.0.1/api", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var status admin.Status
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, admin.Status{
+		Key:       "10.0.0.1/api",
+		Algorithm: "fixed-window",
+		Limit:     2,
+		Count:     2,
+		Remaining: 0,
+		Reset:     clockStart.Add(time.Minute),
+		Denied:    1,
+	}, status)
+
+	w = do(http.MethodGet, "/limited?n=5", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var limited []admin.Status
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &limited))
+	require.Len(t, limited, 1)
+	assert.Equal(t, "10.0.0.1/api", limited[0].Key)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/limited?n=0", "").Code)
+
+	w = do(http.MethodPost, "/overrides/10.0.0.1/api", `{"limit": 5, "ttl": "10m"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, &admin.Override{Limit: 5, Until: clockStart.Add(10 * time.Minute)}, status.Override)
+	assert.Equal(t, 3, status.Remaining)
+	assert.Equal(t, 3, allowN(l, "10.0.0.1/api", 4))
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/overrides/alice", `{"limit": 5}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/overrides/alice", `{"limit": 0, "ttl": "1m"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/overrides/alice", `{"limt": 5, "ttl": "1m"}`).Code)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/overrides/10.0.0.1/api", "").Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/keys/10.0.0.1/api", "").Code)
+	assert.Equal(t, 2, allowN(l, "10.0.0.1/api", 3))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, do(http.MethodPut, "/keys/alice", "").Code)
+}
+
+func TestAdminHandlerRequiresAdminStore(t *testing.T) {
+	l, err := limiter.New(limiter.Config{
+		MaxRequests: 2,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+		Store:       limiter.NewHybridStore(limiter.NewMemoryStore(), limiter.HybridStoreOptions{}),
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	r := httptest.NewRequest(http.MethodGet, "/keys/alice", nil)
+	w := httptest.NewRecorder()
+	admin.NewHandler(l, func(*http.Request) bool { return true }).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+
+	w = httptest.NewRecorder()
+	admin.NewHandler(l, nil).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -5,7 +5,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/NarmadaWeb/limiter/v2"
+	"github.com/NarmadaWeb/limiter/v3"
 	"github.com/stretchr/testify/assert"
 )
 
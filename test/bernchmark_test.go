@@ -2,10 +2,14 @@ package limiter_test
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
-	"github.com/NarmadaWeb/limiter/v2"
+	"github.com/NarmadaWeb/limiter/v3"
 )
 
 func BenchmarkLimiter(b *testing.B) {
@@ -18,3 +22,39 @@ func BenchmarkLimiter(b *testing.B) {
 		_, _, _, _ = store.Take(ctx, key, 1000, time.Minute, "fixed-window")
 	}
 }
+
+const millionKeys = 1_000_000
+
+var benchmarkKeys = sync.OnceValue(func() []string {
+	keys := make([]string, millionKeys)
+	for i := range keys {
+		keys[i] = "10.0." + strconv.Itoa(i>>8) + "." + strconv.Itoa(i&0xff)
+	}
+	return keys
+})
+
+// BenchmarkMemoryStoreMillionKeysParallel measures Take with one million
+// distinct client keys under parallel load for several shard counts.
+func BenchmarkMemoryStoreMillionKeysParallel(b *testing.B) {
+	keys := benchmarkKeys()
+	ctx := context.Background()
+
+	for _, shards := range []int{1, 64, 256} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			store := limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{Shards: shards})
+			for _, key := range keys {
+				_, _, _, _ = store.Take(ctx, key, 100, time.Minute, "fixed-window")
+			}
+
+			var next atomic.Uint64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := next.Add(millionKeys / 8)
+				for pb.Next() {
+					_, _, _, _ = store.Take(ctx, keys[i%millionKeys], 100, time.Minute, "fixed-window")
+					i++
+				}
+			})
+		})
+	}
+}
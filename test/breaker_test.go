@@ -0,0 +1,219 @@
+package limiter_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	redisstore "github.com/NarmadaWeb/limiter/redis/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errStoreDown = errors.New("store down")
+
+// flakyStore wraps a MemoryStore and fails or stalls on demand.
+type flakyStore struct {
+	*limiter.MemoryStore
+	failing atomic.Bool
+	delay   atomic.Int64
+	calls   atomic.Int32
+}
+
+func newFlakyStore() *flakyStore {
+	return &flakyStore{MemoryStore: limiter.NewMemoryStore()}
+}
+
+func (f *flakyStore) Take(ctx context.Context, key string, maxRequests int, window time.Duration, algorithm limiter.Algorithm) (bool, int, time.Time, error) {
+	f.calls.Add(1)
+	if d := time.Duration(f.delay.Load()); d > 0 {
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return false, 0, time.Time{}, ctx.Err()
+		}
+	}
+	if f.failing.Load() {
+		return false, 0, time.Time{}, errStoreDown
+	}
+	return f.MemoryStore.Take(ctx, key, maxRequests, window, algorithm)
+}
+
+type stateRecorder struct {
+	mu          sync.Mutex
+	transitions []limiter.BreakerState
+}
+
+func (s *stateRecorder) record(_, to limiter.BreakerState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transitions = append(s.transitions, to)
+}
+
+func (s *stateRecorder) snapshot() []limiter.BreakerState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]limiter.BreakerState(nil), s.transitions...)
+}
+
+func TestCircuitBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	inner := newFlakyStore()
+	inner.failing.Store(true)
+	breaker := limiter.NewCircuitBreakerStore(inner, limiter.CircuitBreakerOptions{
+		FailureThreshold: 3,
+		Cooldown:         time.Minute,
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, _, _, err := breaker.Take(ctx, "key", 10, time.Minute, "fixed-window")
+		assert.ErrorIs(t, err, errStoreDown)
+	}
+	assert.Equal(t, limiter.BreakerOpen, breaker.State())
+
+	_, _, _, err := breaker.Take(ctx, "key", 10, time.Minute, "fixed-window")
+	assert.ErrorIs(t, err, limiter.ErrCircuitOpen)
+	assert.Equal(t, int32(3), inner.calls.Load(), "open circuit must not reach the store")
+}
+
+func TestCircuitBreakerLatencyThreshold(t *testing.T) {
+	inner := newFlakyStore()
+	inner.delay.Store(int64(20 * time.Millisecond))
+	breaker := limiter.NewCircuitBreakerStore(inner, limiter.CircuitBreakerOptions{
+		FailureThreshold: 2,
+		LatencyThreshold: 5 * time.Millisecond,
+		Cooldown:         time.Minute,
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		allowed, _, _, err := breaker.Take(ctx, "key", 10, time.Minute, "fixed-window")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	assert.Equal(t, limiter.BreakerOpen, breaker.State())
+}
+
+func TestCircuitBreakerTimeout(t *testing.T) {
+	inner := newFlakyStore()
+	inner.delay.Store(int64(time.Second))
+	breaker := limiter.NewCircuitBreakerStore(inner, limiter.CircuitBreakerOptions{
+		Timeout: 10 * time.Millisecond,
+	})
+
+	start := time.Now()
+	_, _, _, err := breaker.Take(context.Background(), "key", 10, time.Minute, "fixed-window")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestCircuitBreakerIgnoresCanceledCalls(t *testing.T) {
+	inner := newFlakyStore()
+	inner.delay.Store(int64(time.Second))
+	breaker := limiter.NewCircuitBreakerStore(inner, limiter.CircuitBreakerOptions{
+		FailureThreshold: 1,
+		Cooldown:         time.Minute,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, _, err := breaker.Take(ctx, "key", 10, time.Minute, "fixed-window")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, limiter.BreakerClosed, breaker.State(), "a canceled call is not a store failure")
+}
+
+func TestCircuitBreakerIgnoresUnsupportedCalls(t *testing.T) {
+	_, client := newTestRedis(t)
+	breaker := limiter.NewCircuitBreakerStore(redisstore.NewStore(client), limiter.CircuitBreakerOptions{
+		FailureThreshold: 1,
+		Cooldown:         time.Minute,
+	})
+
+	err := breaker.Set(context.Background(), "key", 1, time.Minute)
+	assert.ErrorIs(t, err, errors.ErrUnsupported)
+	assert.Equal(t, limiter.BreakerClosed, breaker.State(), "an unsupported call is not a store failure")
+}
+
+func TestCircuitBreakerForwardsTakeN(t *testing.T) {
+	inner := newFlakyStore()
+	breaker := limiter.NewCircuitBreakerStore(inner, limiter.CircuitBreakerOptions{})
+
+	granted, remaining, _, err := breaker.TakeN(context.Background(), "key", 3, 10, time.Minute, "fixed-window")
+	require.NoError(t, err)
+	assert.Equal(t, 3, granted)
+	assert.Equal(t, 7, remaining)
+	assert.Zero(t, inner.calls.Load(), "a BatchStore is asked once, not per request")
+}
+
+func TestCircuitBreakerHalfOpenProbes(t *testing.T) {
+	inner := newFlakyStore()
+	inner.failing.Store(true)
+	states := &stateRecorder{}
+	breaker := limiter.NewCircuitBreakerStore(inner, limiter.CircuitBreakerOptions{
+		FailureThreshold: 1,
+		Cooldown:         20 * time.Millisecond,
+		HalfOpenRequests: 1,
+		OnStateChange:    states.record,
+	})
+	ctx := context.Background()
+
+	_, _, _, err := breaker.Take(ctx, "key", 10, time.Minute, "fixed-window")
+	assert.ErrorIs(t, err, errStoreDown)
+
+	// A failed probe re-opens the circuit.
+	time.Sleep(30 * time.Millisecond)
+	_, _, _, err = breaker.Take(ctx, "key", 10, time.Minute, "fixed-window")
+	assert.ErrorIs(t, err, errStoreDown)
+	assert.Equal(t, limiter.BreakerOpen, breaker.State())
+
+	// A successful probe closes it.
+	inner.failing.Store(false)
+	time.Sleep(30 * time.Millisecond)
+	_, _, _, err = breaker.Take(ctx, "key", 10, time.Minute, "fixed-window")
+	require.NoError(t, err)
+	assert.Equal(t, limiter.BreakerClosed, breaker.State())
+
+	assert.Equal(t, []limiter.BreakerState{
+		limiter.BreakerOpen,
+		limiter.BreakerHalfOpen,
+		limiter.BreakerOpen,
+		limiter.BreakerHalfOpen,
+		limiter.BreakerClosed,
+	}, states.snapshot())
+}
+
+func TestCircuitBreakerWithFailOpen(t *testing.T) {
+	inner := newFlakyStore()
+	inner.delay.Store(int64(time.Second))
+
+	l, err := limiter.New(limiter.Config{
+		Store:         inner,
+		MaxRequests:   1,
+		Window:        time.Minute,
+		Algorithm:     "fixed-window",
+		FailurePolicy: limiter.FailOpen,
+		CircuitBreaker: &limiter.CircuitBreakerOptions{
+			FailureThreshold: 1,
+			Timeout:          10 * time.Millisecond,
+			Cooldown:         time.Minute,
+		},
+	})
+	require.NoError(t, err)
+
+	handler := l.StdLibMiddleware(limiter.StdLibConfig{})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, http.StatusOK, serve(handler))
+	}
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, int32(1), inner.calls.Load())
+	assert.False(t, l.StoreHealthy())
+}
@@ -6,7 +6,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/NarmadaWeb/limiter/v2"
+	"github.com/NarmadaWeb/limiter/v3"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 )
@@ -0,0 +1,332 @@
+package limiter_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	limitergrpc "github.com/NarmadaWeb/limiter/grpc/v3"
+	redisstore "github.com/NarmadaWeb/limiter/redis/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/status"
+)
+
+// newUpstream serves 200 OK with the headers header returns, counting
+// the requests it receives.
+func newUpstream(t *testing.T, header func() http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		code := http.StatusOK
+		if header != nil {
+			for k, v := range header() {
+				w.Header()[k] = v
+			}
+			if w.Header().Get("Retry-After") != "" {
+				code = http.StatusTooManyRequests
+			}
+		}
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func newClientLimiter(t *testing.T, cfg limiter.Config) *limiter.Limiter {
+	t.Helper()
+	l, err := limiter.New(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+func get(t *testing.T, client *http.Client, url string) (int, error) {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func TestTransportFailFast(t *testing.T) {
+	a, hits := newUpstream(t, nil)
+	b, _ := newUpstream(t, nil)
+	l := newClientLimiter(t, limiter.Config{MaxRequests: 2, Window: time.Minute, Algorithm: limiter.FixedWindow})
+	client := &http.Client{Transport: l.Transport(nil, limiter.TransportConfig{})}
+
+	for i := 0; i < 2; i++ {
+		code, err := get(t, client, a.URL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, code)
+	}
+	_, err := get(t, client, a.URL+"/other")
+	var exceeded *limiter.LimitExceededError
+	require.ErrorAs(t, err, &exceeded)
+	assert.ErrorIs(t, err, limiter.ErrLimitExceeded)
+	assert.Equal(t, strings.TrimPrefix(a.URL, "http://"), exceeded.Key)
+	assert.Equal(t, 2, exceeded.Limit)
+	assert.InDelta(t, time.Minute, exceeded.RetryAfter, float64(2*time.Second))
+	assert.Equal(t, int32(2), hits.Load())
+
+	// Every destination host has its own limit.
+	code, err := get(t, client, b.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestTransportWait(t *testing.T) {
+	srv, hits := newUpstream(t, nil)
+	l := newClientLimiter(t, limiter.Config{MaxRequests: 1, Window: 200 * time.Millisecond, Algorithm: limiter.FixedWindow})
+	client := &http.Client{Transport: l.Transport(nil, limiter.TransportConfig{
+		Wait:         true,
+		KeyGenerator: func(*http.Request) string { return "upstream" },
+	})}
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		code, err := get(t, client, srv.URL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, code)
+	}
+	assert.Greater(t, time.Since(start), 100*time.Millisecond)
+	assert.Equal(t, int32(2), hits.Load())
+
+	// A deadline before the reset fails without waiting.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, limiter.ErrLimitExceeded)
+	assert.Equal(t, int32(2), hits.Load())
+}
+
+func TestLimiterWait(t *testing.T) {
+	l := newClientLimiter(t, limiter.Config{MaxRequests: 1, Window: time.Minute, Algorithm: limiter.FixedWindow})
+	ctx := context.Background()
+	require.NoError(t, l.Wait(ctx, "alice"))
+
+	ctx, cancel := context.WithCancel(ctx)
+	time.AfterFunc(20*time.Millisecond, cancel)
+	assert.ErrorIs(t, l.Wait(ctx, "alice"), context.Canceled)
+	require.NoError(t, l.Wait(context.Background(), "bob"))
+}
+
+func TestLimiterWaitDeadlineFollowsClock(t *testing.T) {
+	// The limiter's clock runs an hour ahead, so a deadline in 30 minutes
+	// has passed already.
+	clock := limiter.NewFakeClock(time.Now().Add(time.Hour))
+	l := newClientLimiter(t, limiter.Config{MaxRequests: 1, Window: time.Minute, Algorithm: limiter.FixedWindow, Clock: clock})
+	require.NoError(t, l.Wait(context.Background(), "alice"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- l.Wait(ctx, "alice") }()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, limiter.ErrLimitExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("Wait slept past a deadline the clock says has passed")
+	}
+}
+
+func TestTransportAdaptToUpstreamSharesQuota(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	var remaining atomic.Value
+	remaining.Store("")
+	srv, hits := newUpstream(t, func() http.Header {
+		h := http.Header{}
+		if v := remaining.Load().(string); v != "" {
+			h.Set("RateLimit-Remaining", v)
+		}
+		return h
+	})
+
+	worker := func() *http.Client {
+		l := newClientLimiter(t, limiter.Config{
+			MaxRequests: 10,
+			Window:      time.Minute,
+			Algorithm:   limiter.FixedWindow,
+			Store:       redisstore.NewStoreWithOptions(rdb, redisstore.StoreOptions{}),
+		})
+		return &http.Client{Transport: l.Transport(nil, limiter.TransportConfig{AdaptToUpstream: true})}
+	}
+	a, b := worker(), worker()
+
+	// The upstream has 3 requests left where the limiter had 9: the
+	// difference is taken from the shared store.
+	remaining.Store("3")
+	_, err := get(t, a, srv.URL)
+	require.NoError(t, err)
+
+	remaining.Store("")
+	for i := 0; i < 3; i++ {
+		_, err := get(t, b, srv.URL)
+		require.NoError(t, err)
+	}
+	_, err = get(t, b, srv.URL)
+	assert.ErrorIs(t, err, limiter.ErrLimitExceeded)
+	_, err = get(t, a, srv.URL)
+	assert.ErrorIs(t, err, limiter.ErrLimitExceeded)
+	assert.Equal(t, int32(4), hits.Load())
+}
+
+func TestTransportAdaptToRetryAfter(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	srv, hits := newUpstream(t, func() http.Header {
+		return http.Header{"Retry-After": {"30"}}
+	})
+	worker := func() *http.Client {
+		l := newClientLimiter(t, limiter.Config{
+			MaxRequests: 10,
+			Window:      time.Minute,
+			Algorithm:   limiter.FixedWindow,
+			Store:       redisstore.NewStoreWithOptions(rdb, redisstore.StoreOptions{}),
+		})
+		return &http.Client{Transport: l.Transport(nil, limiter.TransportConfig{AdaptToUpstream: true})}
+	}
+	a, b := worker(), worker()
+
+	code, err := get(t, a, srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, code)
+
+	_, err = get(t, a, srv.URL)
+	var exceeded *limiter.LimitExceededError
+	require.ErrorAs(t, err, &exceeded)
+	assert.InDelta(t, 30*time.Second, exceeded.RetryAfter, float64(2*time.Second))
+
+	// The rest of the window was taken for the other workers too.
+	_, err = get(t, b, srv.URL)
+	assert.ErrorIs(t, err, limiter.ErrLimitExceeded)
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestTransportAdaptToXRateLimitReset(t *testing.T) {
+	clock := limiter.NewFakeClock(clockStart)
+	srv, _ := newUpstream(t, func() http.Header {
+		return http.Header{
+			"X-Ratelimit-Remaining": {"0"},
+			"X-Ratelimit-Reset":     {strconv.FormatInt(clockStart.Add(20*time.Second).Unix(), 10)},
+		}
+	})
+	l := newClientLimiter(t, limiter.Config{MaxRequests: 10, Window: time.Minute, Algorithm: limiter.FixedWindow, Clock: clock})
+	client := &http.Client{Transport: l.Transport(nil, limiter.TransportConfig{AdaptToUpstream: true})}
+
+	_, err := get(t, client, srv.URL)
+	require.NoError(t, err)
+	_, err = get(t, client, srv.URL)
+	var exceeded *limiter.LimitExceededError
+	require.ErrorAs(t, err, &exceeded)
+	assert.Equal(t, 20*time.Second, exceeded.RetryAfter)
+	assert.Equal(t, 0, exceeded.Remaining)
+}
+
+func TestGRPCClientInterceptors(t *testing.T) {
+	ctx := context.Background()
+	client := newClientLimiter(t, limiter.Config{MaxRequests: 1, Window: time.Minute, Algorithm: limiter.FixedWindow})
+	var keys []string
+	cfg := limitergrpc.ClientConfig{
+		KeyGenerator: func(ctx context.Context, method string, cc *grpc.ClientConn) string {
+			keys = append(keys, limitergrpc.TargetKey(ctx, method, cc))
+			return "upstream"
+		},
+	}
+	svc := newGRPCTest(t, newGRPCLimiter(t, 10), limitergrpc.Config{}, &testService{},
+		grpc.WithUnaryInterceptor(limitergrpc.UnaryClientInterceptor(client, cfg)),
+		grpc.WithStreamInterceptor(limitergrpc.StreamClientInterceptor(client, cfg)),
+	)
+
+	_, err := svc.EmptyCall(ctx, &testpb.Empty{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"passthrough:///bufnet"}, keys)
+
+	_, err = svc.EmptyCall(ctx, &testpb.Empty{})
+	st := status.Convert(err)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+
+	_, err = svc.StreamingInputCall(ctx)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// Waiting past the deadline fails at once.
+	waiting := newGRPCTest(t, newGRPCLimiter(t, 10), limitergrpc.Config{}, &testService{},
+		grpc.WithUnaryInterceptor(limitergrpc.UnaryClientInterceptor(client, limitergrpc.ClientConfig{
+			KeyGenerator: cfg.KeyGenerator,
+			Wait:         true,
+		})),
+	)
+	deadline, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = waiting.EmptyCall(deadline, &testpb.Empty{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestGRPCClientAdaptKeepsEOF(t *testing.T) {
+	ctx := context.Background()
+	client := newClientLimiter(t, limiter.Config{MaxRequests: 10, Window: time.Minute, Algorithm: limiter.FixedWindow})
+	svc := newGRPCTest(t, newGRPCLimiter(t, 10), limitergrpc.Config{}, &testService{},
+		grpc.WithStreamInterceptor(limitergrpc.StreamClientInterceptor(client, limitergrpc.ClientConfig{AdaptToUpstream: true})),
+	)
+
+	// A stream ending before its first message reports io.EOF.
+	stream, err := svc.StreamingOutputCall(ctx, &testpb.StreamingOutputCallRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestGRPCClientAdaptToUpstream(t *testing.T) {
+	for name, headers := range map[string]limiter.HeaderMode{
+		"metadata":   limiter.HeadersIETF,
+		"retry info": limiter.HeadersNone,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			errLocal := errors.New("held back")
+			client := newClientLimiter(t, limiter.Config{MaxRequests: 10, Window: time.Minute, Algorithm: limiter.FixedWindow})
+			cfg := limitergrpc.ClientConfig{
+				AdaptToUpstream: true,
+				LimitReachedHandler: func(_ context.Context, _ string, retryAfter time.Duration) error {
+					assert.InDelta(t, time.Minute, retryAfter, float64(2*time.Second))
+					return status.Error(codes.Aborted, errLocal.Error())
+				},
+			}
+			svc := newGRPCTest(t, newGRPCLimiter(t, 1), limitergrpc.Config{Headers: headers}, &testService{},
+				grpc.WithUnaryInterceptor(limitergrpc.UnaryClientInterceptor(client, cfg)),
+				grpc.WithStreamInterceptor(limitergrpc.StreamClientInterceptor(client, cfg)),
+			)
+
+			_, err := svc.EmptyCall(ctx, &testpb.Empty{})
+			require.NoError(t, err)
+			if headers == limiter.HeadersNone {
+				// The upstream says nothing until it rejects a call.
+				_, err = svc.EmptyCall(ctx, &testpb.Empty{})
+				require.Equal(t, codes.ResourceExhausted, status.Code(err))
+			}
+
+			_, err = svc.EmptyCall(ctx, &testpb.Empty{})
+			assert.Equal(t, codes.Aborted, status.Code(err))
+			_, err = svc.StreamingInputCall(ctx)
+			assert.Equal(t, codes.Aborted, status.Code(err))
+		})
+	}
+}
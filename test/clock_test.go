@@ -0,0 +1,143 @@
+package limiter_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	redisstore "github.com/NarmadaWeb/limiter/redis/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var clockStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeClockFixedWindowReset(t *testing.T) {
+	clock := limiter.NewFakeClock(clockStart)
+	store := limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{Clock: clock})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		allowed, _, reset, err := store.Take(ctx, "client", 2, time.Hour, "fixed-window")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, clockStart.Add(time.Hour), reset)
+	}
+
+	clock.Advance(59 * time.Minute)
+	allowed, _, _, err := store.Take(ctx, "client", 2, time.Hour, "fixed-window")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	clock.Advance(time.Minute + time.Nanosecond)
+	allowed, remaining, _, err := store.Take(ctx, "client", 2, time.Hour, "fixed-window")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 1, remaining)
+}
+
+func TestFakeClockTokenBucketRefill(t *testing.T) {
+	clock := limiter.NewFakeClock(clockStart)
+	store := limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{Clock: clock})
+	ctx := context.Background()
+
+	// 10 tokens per 10 seconds refills one token per second.
+	for i := 0; i < 10; i++ {
+		allowed, _, _, err := store.Take(ctx, "client", 10, 10*time.Second, "token-bucket")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+
+	allowed, _, reset, err := store.Take(ctx, "client", 10, 10*time.Second, "token-bucket")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, clockStart.Add(time.Second), reset)
+
+	clock.Advance(2500 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		allowed, _, _, err = store.Take(ctx, "client", 10, 10*time.Second, "token-bucket")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, _, _, err = store.Take(ctx, "client", 10, 10*time.Second, "token-bucket")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestFakeClockSlidingWindow(t *testing.T) {
+	clock := limiter.NewFakeClock(clockStart)
+	store := limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{Clock: clock})
+	ctx := context.Background()
+
+	take := func() bool {
+		allowed, _, _, err := store.Take(ctx, "client", 2, time.Minute, "sliding-window")
+		require.NoError(t, err)
+		return allowed
+	}
+
+	assert.True(t, take())
+	clock.Advance(30 * time.Second)
+	assert.True(t, take())
+	assert.False(t, take())
+
+	// Only the first hit has left the window.
+	clock.Advance(30*time.Second + time.Millisecond)
+	assert.True(t, take())
+	assert.False(t, take())
+}
+
+func TestFakeClockRetryAfterHeader(t *testing.T) {
+	clock := limiter.NewFakeClock(clockStart)
+	l, err := limiter.New(limiter.Config{
+		MaxRequests: 1,
+		Window:      30 * time.Second,
+		Algorithm:   "fixed-window",
+		Clock:       clock,
+	})
+	require.NoError(t, err)
+	defer l.Close()
+
+	handler := l.StdLibMiddleware(limiter.StdLibConfig{})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	do := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, do().Code)
+
+	w := do()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+	clock.Advance(10 * time.Second)
+	assert.Equal(t, "20", do().Header().Get("Retry-After"))
+
+	clock.Advance(21 * time.Second)
+	assert.Equal(t, http.StatusOK, do().Code)
+}
+
+func TestFakeClockRedisTokenBucket(t *testing.T) {
+	_, client := newTestRedis(t)
+	clock := limiter.NewFakeClock(clockStart)
+	store := redisstore.NewStoreWithOptions(client, redisstore.StoreOptions{Clock: clock})
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		allowed, _, _, err := store.Take(ctx, "client", 4, 4*time.Second, "token-bucket")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, _, reset, err := store.Take(ctx, "client", 4, 4*time.Second, "token-bucket")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, clockStart.Add(time.Second), reset.UTC())
+
+	clock.Advance(time.Second)
+	allowed, _, _, err = store.Take(ctx, "client", 4, 4*time.Second, "token-bucket")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
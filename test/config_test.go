@@ -0,0 +1,303 @@
+package limiter_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NarmadaWeb/limiter/config/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const yamlPolicy = `
+store:
+  type: redis
+  redis:
+    url: redis://127.0.0.1:1/0
+    namespace: shop
+limiters:
+  api:
+    max_requests: 2
+    window: 1m
+    algorithm: sliding-window
+    failure_policy: fail-open
+    shadow:
+      - name: strict
+        max_requests: 1
+        window: 30s
+  login:
+    max_requests: 1
+    window: 15m
+    algorithm: fixed-window
+rules:
+  - route: POST /login
+    limiter: login
+  - route: /api/
+    limiter: api
+    key: header:X-Api-Key
+    headers: ietf
+`
+
+const jsonPolicy = `{
+  "store": {"type": "redis", "redis": {"url": "redis://127.0.0.1:1/0", "namespace": "shop"}},
+  "limiters": {
+    "api": {"max_requests": 2, "window": "1m", "algorithm": "sliding-window", "failure_policy": "fail-open",
+            "shadow": [{"name": "strict", "max_requests": 1, "window": "30s"}]},
+    "login": {"max_requests": 1, "window": "15m", "algorithm": "fixed-window"}
+  },
+  "rules": [
+    {"route": "POST /login", "limiter": "login"},
+    {"route": "/api/", "limiter": "api", "key": "header:X-Api-Key", "headers": "ietf"}
+  ]
+}`
+
+const tomlPolicy = `
+[store]
+type = "redis"
+[store.redis]
+url = "redis://127.0.0.1:1/0"
+namespace = "shop"
+
+[limiters.api]
+max_requests = 2
+window = "1m"
+algorithm = "sliding-window"
+failure_policy = "fail-open"
+[[limiters.api.shadow]]
+name = "strict"
+max_requests = 1
+window = "30s"
+
+[limiters.login]
+max_requests = 1
+window = "15m"
+algorithm = "fixed-window"
+
+[[rules]]
+route = "POST /login"
+limiter = "login"
+
+[[rules]]
+route = "/api/"
+limiter = "api"
+key = "header:X-Api-Key"
+headers = "ietf"
+`
+
+func TestConfigFormatsAgree(t *testing.T) {
+	want, err := config.Parse([]byte(yamlPolicy), config.Options{NoEnv: true})
+	require.NoError(t, err)
+	assert.Equal(t, config.Duration(time.Minute), want.Limiters["api"].Window)
+	assert.Equal(t, "header:X-Api-Key", want.Rules[1].Key)
+
+	for format, data := range map[config.Format]string{config.JSON: jsonPolicy, config.TOML: tomlPolicy} {
+		got, err := config.Parse([]byte(data), config.Options{Format: format, NoEnv: true})
+		require.NoError(t, err, format)
+		assert.Equal(t, want, got, format)
+	}
+}
+
+func TestConfigLoadPicksFormatFromExtension(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{"policy.yml": yamlPolicy, "policy.json": jsonPolicy, "policy.toml": tomlPolicy} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+		f, err := config.LoadWithOptions(path, config.Options{NoEnv: true})
+		require.NoError(t, err, name)
+		assert.Len(t, f.Limiters, 2, name)
+	}
+
+	path := filepath.Join(dir, "policy.ini")
+	require.NoError(t, os.WriteFile(path, []byte(yamlPolicy), 0o600))
+	_, err := config.Load(path)
+	assert.ErrorIs(t, err, limiter.ErrInvalidConfig)
+}
+
+func TestConfigEnvOverrides(t *testing.T) {
+	f, err := config.Parse([]byte(yamlPolicy), config.Options{Environ: []string{
+		"LIMITER_LIMITERS_API_MAX_REQUESTS=500",
+		"LIMITER_LIMITERS_API_WINDOW=10s",
+		"LIMITER_LIMITERS_API_SHADOW_0_MAX_REQUESTS=50",
+		"LIMITER_STORE_REDIS_URL=redis://redis:6379/1",
+		"LIMITER_RULES_1_HEADERS=none",
+		"OTHER_LIMITERS_API_MAX_REQUESTS=1",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, 500, f.Limiters["api"].MaxRequests)
+	assert.Equal(t, config.Duration(10*time.Second), f.Limiters["api"].Window)
+	assert.Equal(t, 50, f.Limiters["api"].Shadow[0].MaxRequests)
+	assert.Equal(t, "redis://redis:6379/1", f.Store.Redis.URL)
+	assert.Equal(t, "none", f.Rules[1].Headers)
+	assert.Equal(t, 1, f.Limiters["login"].MaxRequests)
+
+	// A custom prefix, and a section absent from the file.
+	f, err = config.Parse([]byte("limiters: {api: {max_requests: 1, window: 1m, algorithm: token-bucket}}"), config.Options{
+		EnvPrefix: "APP",
+		Environ:   []string{"APP_STORE_MEMORY_MAX_KEYS=1000"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, f.Store.Memory)
+	assert.Equal(t, 1000, f.Store.Memory.MaxKeys)
+
+	_, err = config.Parse([]byte(yamlPolicy), config.Options{Environ: []string{"LIMITER_LIMITERS_API_MAX_REQUESTS=many"}})
+	var cfgErr *limiter.ConfigError
+	require.ErrorAs(t, err, &cfgErr)
+	assert.Equal(t, "limiters.api.max_requests", cfgErr.Fields[0].Field)
+}
+
+func TestConfigValidation(t *testing.T) {
+	_, err := config.Parse([]byte(`
+store:
+  type: redis
+limiters:
+  api:
+    max_requests: 0
+    window: 1m
+    algorithm: leaky
+    failure_policy: pray
+    shadow:
+      - max_requests: 1
+        window: 1m
+rules:
+  - route: /api/
+    limiter: missing
+    key: cookie
+    headers: loud
+  - route: /api/
+    limiter: api
+`), config.Options{NoEnv: true})
+
+	var cfgErr *limiter.ConfigError
+	require.ErrorAs(t, err, &cfgErr)
+	var fields []string
+	for _, f := range cfgErr.Fields {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(t, []string{
+		"store.redis.url",
+		"limiters.api.max_requests",
+		"limiters.api.algorithm",
+		"limiters.api.failure_policy",
+		"limiters.api.shadow.0.name",
+		"rules.0.limiter",
+		"rules.0.key",
+		"rules.0.headers",
+		"rules.1.route",
+	}, fields)
+	assert.ErrorIs(t, err, limiter.ErrInvalidConfig)
+	assert.ErrorIs(t, err, limiter.ErrInvalidAlgorithm)
+}
+
+func TestConfigRejectsUnknownFields(t *testing.T) {
+	_, err := config.Parse([]byte("limiters: {api: {max_request: 1, window: 1m, algorithm: fixed-window}}"), config.Options{NoEnv: true})
+	require.ErrorIs(t, err, limiter.ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "max_request")
+
+	_, err = config.Parse([]byte("limiters: {api: {max_requests: 1, window: 60, algorithm: fixed-window}}"), config.Options{NoEnv: true})
+	require.ErrorIs(t, err, limiter.ErrInvalidConfig)
+}
+
+func TestConfigSetRoutesRequests(t *testing.T) {
+	mr := miniredis.RunT(t)
+	f, err := config.Parse([]byte(yamlPolicy), config.Options{Environ: []string{"LIMITER_STORE_REDIS_URL=" + mr.Addr()}})
+	require.NoError(t, err)
+
+	set, err := config.New(f)
+	require.NoError(t, err)
+	defer func() { _ = set.Close() }()
+	require.NotNil(t, set.Limiter("api"))
+	assert.Nil(t, set.Limiter("missing"))
+
+	handler := set.Middleware()(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	do := func(method, path, apiKey string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		r.Header.Set("X-Api-Key", apiKey)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	// login: one request per IP, default headers.
+	w := do(http.MethodPost, "/login", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, http.StatusTooManyRequests, do(http.MethodPost, "/login", "").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/login", "").Code, "GET /login matches no rule")
+
+	// api: two requests per API key, IETF headers.
+	w = do(http.MethodGet, "/api/orders", "a")
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+	assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/orders", "a").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do(http.MethodGet, "/api/orders", "a").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/orders", "b").Code)
+
+	// Requests outside every route pass untouched, without a redirect.
+	w = do(http.MethodGet, "/api", "a")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+
+	// Counters live in the shared Redis under each limiter's name.
+	keys := mr.Keys()
+	assert.Contains(t, keys, "rate_limit:shop:api:sliding-window:a")
+	assert.Contains(t, keys, "rate_limit:shop:login:fixed-window:192.0.2.1")
+	assert.True(t, slicesContainPrefix(keys, "rate_limit:shop:api:sliding-window:shadow:strict:"))
+}
+
+func init() {
+	if err := config.RegisterKeyStrategy("tenant", func(r *http.Request) string {
+		return strings.SplitN(r.Host, ".", 2)[0]
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func TestConfigCustomKeyStrategy(t *testing.T) {
+	require.ErrorIs(t, config.RegisterKeyStrategy("tenant", func(*http.Request) string { return "" }), limiter.ErrInvalidConfig)
+	require.ErrorIs(t, config.RegisterKeyStrategy("ip", func(*http.Request) string { return "" }), limiter.ErrInvalidConfig)
+
+	f, err := config.Parse([]byte(`
+limiters:
+  tenants: {max_requests: 1, window: 1m, algorithm: token-bucket}
+rules:
+  - {route: /, limiter: tenants, key: tenant, headers: none}
+`), config.Options{NoEnv: true})
+	require.NoError(t, err)
+	set, err := config.New(f)
+	require.NoError(t, err)
+	defer func() { _ = set.Close() }()
+
+	handler := set.Middleware()(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	for _, tc := range []struct {
+		host string
+		want int
+	}{
+		{"acme.example.com", http.StatusOK},
+		{"acme.example.org", http.StatusTooManyRequests},
+		{"globex.example.com", http.StatusOK},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "http://"+tc.host+"/", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, tc.want, w.Code, tc.host)
+		assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+	}
+}
+
+func slicesContainPrefix(values []string, prefix string) bool {
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			return true
+		}
+	}
+	return false
+}
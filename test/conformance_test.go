@@ -0,0 +1,52 @@
+package limiter_test
+
+import (
+	"testing"
+	"time"
+
+	redisstore "github.com/NarmadaWeb/limiter/redis/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/NarmadaWeb/limiter/v3/limitertest"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// miniredisClock advances a FakeClock together with the miniredis TTLs and
+// server time.
+type miniredisClock struct {
+	*limiter.FakeClock
+	mr *miniredis.Miniredis
+}
+
+func (c miniredisClock) Advance(d time.Duration) {
+	c.FakeClock.Advance(d)
+	c.mr.SetTime(c.Now())
+	c.mr.FastForward(d)
+}
+
+func TestMemoryStoreConformance(t *testing.T) {
+	clock := limiter.NewFakeClock(clockStart)
+	limitertest.RunStoreConformance(t, func() limiter.Store {
+		return limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{Clock: clock})
+	}, limitertest.WithClock(clock))
+}
+
+func TestRedisStoreConformance(t *testing.T) {
+	for name, serverTime := range map[string]bool{"client-time": false, "server-time": true} {
+		t.Run(name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			clock := miniredisClock{FakeClock: limiter.NewFakeClock(clockStart), mr: mr}
+			mr.SetTime(clockStart)
+
+			limitertest.RunStoreConformance(t, func() limiter.Store {
+				mr.FlushAll()
+				// The suite closes every store, which closes its client.
+				client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+				return redisstore.NewStoreWithOptions(client, redisstore.StoreOptions{
+					Clock:         clock,
+					UseServerTime: serverTime,
+				})
+			}, limitertest.WithClock(clock))
+		})
+	}
+}
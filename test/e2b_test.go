@@ -5,24 +5,29 @@ import (
 	"net/http/httptest"
 	"testing"
 	"time"
+	"unsafe"
 
-	"github.com/NarmadaWeb/limiter/v2"
+	limiterfiber "github.com/NarmadaWeb/limiter/fiber/v3"
+	"github.com/NarmadaWeb/limiter/v3"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEndToEnd(t *testing.T) {
 	app := fiber.New()
 
+	clock := limiter.NewFakeClock(time.Now())
 	limiterCfg := limiter.Config{
 		MaxRequests: 2,
 		Window:      1 * time.Second,
 		Algorithm:   "fixed-window",
+		Clock:       clock,
 	}
 	l, err := limiter.New(limiterCfg)
 	assert.NoError(t, err)
 
-	app.Use(l.FiberMiddleware(limiter.FiberConfig{}))
+	app.Use(limiterfiber.New(l, limiterfiber.Config{}))
 
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.SendString("OK")
@@ -43,9 +48,39 @@ func TestEndToEnd(t *testing.T) {
 	// Third request should fail
 	assert.Equal(t, http.StatusTooManyRequests, makeRequest())
 
-	// Wait for window to reset
-	time.Sleep(1100 * time.Millisecond) // Slightly more than the window
+	// Move past the window reset
+	clock.Advance(1100 * time.Millisecond) // Slightly more than the window
 
 	// After reset, next request should succeed
 	assert.Equal(t, http.StatusOK, makeRequest())
 }
+
+func TestFiberKeyOutlivesRequestBuffer(t *testing.T) {
+	l, err := limiter.New(limiter.Config{MaxRequests: 1, Window: time.Minute, Algorithm: limiter.FixedWindow})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	// Like c.Get, the key points into a buffer that is overwritten once the
+	// request is done.
+	var buf []byte
+	app := fiber.New()
+	app.Use(limiterfiber.New(l, limiterfiber.Config{
+		KeyGenerator: func(c *fiber.Ctx) string {
+			buf = []byte(c.Get("X-Api-Key"))
+			return unsafe.String(unsafe.SliceData(buf), len(buf))
+		},
+	}))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("OK") })
+
+	request := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Api-Key", "client-a")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		copy(buf, "xxxxxxxx")
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, request())
+	assert.Equal(t, http.StatusTooManyRequests, request())
+}
@@ -6,7 +6,8 @@ import (
 	"testing"
 	"time"
 
-	"github.com/NarmadaWeb/limiter/v2"
+	limiterecho "github.com/NarmadaWeb/limiter/echo/v3"
+	"github.com/NarmadaWeb/limiter/v3"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 )
@@ -22,7 +23,7 @@ func TestEchoMiddleware(t *testing.T) {
 	l, err := limiter.New(cfg)
 	assert.NoError(t, err)
 
-	e.Use(l.EchoMiddleware(limiter.EchoConfig{}))
+	e.Use(limiterecho.New(l, limiterecho.Config{}))
 
 	e.GET("/", func(c echo.Context) error {
 		return c.String(http.StatusOK, "OK")
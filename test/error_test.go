@@ -0,0 +1,104 @@
+package limiter_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	redisstore "github.com/NarmadaWeb/limiter/redis/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigErrorListsEveryField(t *testing.T) {
+	_, err := limiter.New(limiter.Config{
+		MaxRequests:   0,
+		Window:        -time.Second,
+		Algorithm:     "leaky-bucket",
+		FallbackScale: -1,
+	})
+	require.Error(t, err)
+
+	var cfgErr *limiter.ConfigError
+	require.ErrorAs(t, err, &cfgErr)
+	fields := make([]string, 0, len(cfgErr.Fields))
+	for _, f := range cfgErr.Fields {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(t, []string{"MaxRequests", "Window", "Algorithm", "FallbackScale"}, fields)
+
+	assert.ErrorIs(t, err, limiter.ErrInvalidConfig)
+	assert.ErrorIs(t, err, limiter.ErrInvalidAlgorithm)
+	assert.Contains(t, err.Error(), "MaxRequests: must be positive")
+}
+
+func TestConfigErrorNamesValidAlgorithms(t *testing.T) {
+	_, err := limiter.New(limiter.Config{MaxRequests: 1, Window: time.Minute, Algorithm: "fixed_window"})
+
+	assert.ErrorIs(t, err, limiter.ErrInvalidAlgorithm)
+	assert.Contains(t, err.Error(), `Algorithm: unknown algorithm "fixed_window", want token-bucket, sliding-window, fixed-window`)
+}
+
+func TestConfigErrorWithValidAlgorithm(t *testing.T) {
+	_, err := limiter.New(limiter.Config{Window: time.Minute, Algorithm: limiter.FixedWindow})
+
+	assert.ErrorIs(t, err, limiter.ErrInvalidConfig)
+	assert.NotErrorIs(t, err, limiter.ErrInvalidAlgorithm)
+}
+
+func TestAllowReturnsLimitExceededError(t *testing.T) {
+	clock := limiter.NewFakeClock(clockStart)
+	l, err := limiter.New(limiter.Config{
+		MaxRequests: 2,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+		Clock:       clock,
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+	ctx := context.Background()
+
+	require.NoError(t, l.Allow(ctx, "client"))
+	require.NoError(t, l.Allow(ctx, "client"))
+
+	clock.Advance(20 * time.Second)
+	err = l.Allow(ctx, "client")
+	require.ErrorIs(t, err, limiter.ErrLimitExceeded)
+
+	var limitErr *limiter.LimitExceededError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, "client", limitErr.Key)
+	assert.Equal(t, 2, limitErr.Limit)
+	assert.Equal(t, 0, limitErr.Remaining)
+	assert.Equal(t, clockStart.Add(time.Minute), limitErr.Reset)
+	assert.Equal(t, 40*time.Second, limitErr.RetryAfter)
+}
+
+func TestRedisErrorsWrapSentinels(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1, DialerRetries: 1})
+	t.Cleanup(func() { _ = client.Close() })
+	store := redisstore.NewStore(client)
+	ctx := context.Background()
+
+	err := store.Set(ctx, "client", 1, time.Minute)
+	assert.ErrorIs(t, err, limiter.ErrStorage)
+	assert.ErrorIs(t, err, errors.ErrUnsupported)
+
+	mr.Close()
+	_, _, _, err = store.Take(ctx, "client", 1, time.Minute, limiter.FixedWindow)
+	assert.ErrorIs(t, err, limiter.ErrStorage)
+	assert.ErrorIs(t, err, limiter.ErrRedisConnection)
+
+	_, err = store.Get(ctx, "client")
+	assert.ErrorIs(t, err, limiter.ErrRedisConnection)
+	assert.ErrorIs(t, store.Rollback(ctx, "client"), limiter.ErrRedisConnection)
+}
+
+func TestCircuitOpenIsStorageError(t *testing.T) {
+	assert.ErrorIs(t, limiter.ErrCircuitOpen, limiter.ErrStorage)
+}
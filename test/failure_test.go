@@ -0,0 +1,169 @@
+package limiter_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	redisstore "github.com/NarmadaWeb/limiter/redis/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type healthRecorder struct {
+	mu     sync.Mutex
+	events []bool
+}
+
+func (h *healthRecorder) record(healthy bool, _ error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, healthy)
+}
+
+func (h *healthRecorder) snapshot() []bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]bool(nil), h.events...)
+}
+
+func newFailureTestLimiter(t *testing.T, cfg limiter.Config) (*miniredis.Miniredis, http.Handler) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1, DialerRetries: 1})
+	t.Cleanup(func() { _ = client.Close() })
+
+	cfg.Store = redisstore.NewStore(client)
+	cfg.Window = time.Minute
+	cfg.Algorithm = "fixed-window"
+	l, err := limiter.New(cfg)
+	require.NoError(t, err)
+
+	return mr, l.StdLibMiddleware(limiter.StdLibConfig{})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func serve(handler http.Handler) int {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w.Code
+}
+
+func TestFailClosedReturnsError(t *testing.T) {
+	mr, handler := newFailureTestLimiter(t, limiter.Config{MaxRequests: 5})
+	mr.Close()
+
+	assert.Equal(t, http.StatusInternalServerError, serve(handler))
+}
+
+func TestFailOpenAllowsRequests(t *testing.T) {
+	health := &healthRecorder{}
+	mr, handler := newFailureTestLimiter(t, limiter.Config{
+		MaxRequests:         1,
+		FailurePolicy:       limiter.FailOpen,
+		OnStoreHealthChange: health.record,
+	})
+
+	assert.Equal(t, http.StatusOK, serve(handler))
+	assert.Equal(t, http.StatusTooManyRequests, serve(handler))
+
+	mr.Close()
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, serve(handler))
+	}
+	assert.Equal(t, []bool{false}, health.snapshot())
+}
+
+func TestFallbackToLocalScalesLimitAndRecovers(t *testing.T) {
+	health := &healthRecorder{}
+	mr, handler := newFailureTestLimiter(t, limiter.Config{
+		MaxRequests:         4,
+		FailurePolicy:       limiter.FallbackToLocal,
+		FallbackScale:       0.5,
+		RecoveryInterval:    20 * time.Millisecond,
+		OnStoreHealthChange: health.record,
+	})
+
+	mr.Close()
+	assert.Equal(t, http.StatusOK, serve(handler))
+	assert.Equal(t, http.StatusOK, serve(handler))
+	assert.Equal(t, http.StatusTooManyRequests, serve(handler))
+	assert.Equal(t, []bool{false}, health.snapshot())
+
+	require.NoError(t, mr.Restart())
+	time.Sleep(30 * time.Millisecond)
+
+	// Redis is back with fresh counters, so the full limit applies again.
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, http.StatusOK, serve(handler))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, serve(handler))
+	assert.Equal(t, []bool{false, true}, health.snapshot())
+}
+
+func TestFallbackHeadersShowScaledLimit(t *testing.T) {
+	store := newFlakyStore()
+	store.failing.Store(true)
+	l, err := limiter.New(limiter.Config{
+		Store:         store,
+		MaxRequests:   4,
+		Window:        10 * time.Second,
+		Algorithm:     limiter.FixedWindow,
+		FailurePolicy: limiter.FallbackToLocal,
+		FallbackScale: 0.5,
+	})
+	require.NoError(t, err)
+	defer l.Close()
+
+	handler := l.StdLibMiddleware(limiter.StdLibConfig{})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "4;w=10", w.Header().Get("RateLimit-Policy"))
+}
+
+func TestCanceledRequestKeepsStoreHealthy(t *testing.T) {
+	health := &healthRecorder{}
+	store := newFlakyStore()
+	store.delay.Store(int64(time.Second))
+	l, err := limiter.New(limiter.Config{
+		Store:               store,
+		MaxRequests:         1,
+		Window:              time.Minute,
+		Algorithm:           limiter.FixedWindow,
+		FailurePolicy:       limiter.FallbackToLocal,
+		OnStoreHealthChange: health.record,
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	// A client giving up is not a store failure.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.Allow(ctx, "alice"), context.DeadlineExceeded)
+	assert.True(t, l.StoreHealthy())
+	assert.Empty(t, health.snapshot())
+
+	store.delay.Store(0)
+	require.NoError(t, l.Allow(context.Background(), "alice"))
+	assert.Equal(t, int32(2), store.calls.Load())
+}
+
+func TestInvalidFailurePolicy(t *testing.T) {
+	_, err := limiter.New(limiter.Config{
+		MaxRequests:   1,
+		Window:        time.Minute,
+		Algorithm:     "fixed-window",
+		FailurePolicy: limiter.FailurePolicy(42),
+	})
+	assert.Error(t, err)
+}
@@ -6,7 +6,8 @@ import (
 	"testing"
 	"time"
 
-	"github.com/NarmadaWeb/limiter/v2"
+	limitergin "github.com/NarmadaWeb/limiter/gin/v3"
+	"github.com/NarmadaWeb/limiter/v3"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
@@ -23,7 +24,7 @@ func TestGinMiddleware(t *testing.T) {
 	l, err := limiter.New(cfg)
 	assert.NoError(t, err)
 
-	router.Use(l.GinMiddleware(limiter.GinConfig{}))
+	router.Use(limitergin.New(l, limitergin.Config{}))
 
 	router.GET("/", func(c *gin.Context) {
 		c.String(http.StatusOK, "OK")
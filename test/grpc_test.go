@@ -0,0 +1,222 @@
+package limiter_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	limitergrpc "github.com/NarmadaWeb/limiter/grpc/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testService answers EmptyCall, counts the messages of
+// StreamingInputCall and ends StreamingOutputCall without a message.
+type testService struct {
+	testpb.UnimplementedTestServiceServer
+	fail bool
+}
+
+func (s *testService) EmptyCall(context.Context, *testpb.Empty) (*testpb.Empty, error) {
+	if s.fail {
+		return nil, status.Error(codes.Internal, "boom")
+	}
+	return &testpb.Empty{}, nil
+}
+
+func (s *testService) StreamingInputCall(stream testpb.TestService_StreamingInputCallServer) error {
+	var n int32
+	for {
+		_, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(&testpb.StreamingInputCallResponse{AggregatedPayloadSize: n})
+		}
+		if err != nil {
+			return err
+		}
+		n++
+	}
+}
+
+func (s *testService) StreamingOutputCall(*testpb.StreamingOutputCallRequest, testpb.TestService_StreamingOutputCallServer) error {
+	return nil
+}
+
+// newGRPCTest serves svc over bufconn with the interceptors of l. The
+// client connection is made with opts.
+func newGRPCTest(t *testing.T, l *limiter.Limiter, cfg limitergrpc.Config, svc *testService, opts ...grpc.DialOption) testpb.TestServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(limitergrpc.UnaryServerInterceptor(l, cfg)),
+		grpc.StreamInterceptor(limitergrpc.StreamServerInterceptor(l, cfg)),
+	)
+	testpb.RegisterTestServiceServer(server, svc)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet", append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return testpb.NewTestServiceClient(conn)
+}
+
+func newGRPCLimiter(t *testing.T, maxRequests int) *limiter.Limiter {
+	t.Helper()
+	l, err := limiter.New(limiter.Config{
+		MaxRequests: maxRequests,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+func TestGRPCUnaryInterceptor(t *testing.T) {
+	client := newGRPCTest(t, newGRPCLimiter(t, 2), limitergrpc.Config{}, &testService{})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		var header metadata.MD
+		_, err := client.EmptyCall(ctx, &testpb.Empty{}, grpc.Header(&header))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"2"}, header.Get("x-ratelimit-limit"))
+		assert.Equal(t, []string{[]string{"1", "0"}[i]}, header.Get("x-ratelimit-remaining"))
+	}
+
+	var header metadata.MD
+	_, err := client.EmptyCall(ctx, &testpb.Empty{}, grpc.Header(&header))
+	st := status.Convert(err)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+	assert.Equal(t, "rate limit exceeded", st.Message())
+	require.Len(t, st.Details(), 1)
+	retry, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.InDelta(t, time.Minute, retry.GetRetryDelay().AsDuration(), float64(2*time.Second))
+	assert.Equal(t, []string{"0"}, header.Get("x-ratelimit-remaining"))
+}
+
+func TestGRPCKeyGenerators(t *testing.T) {
+	ctx := context.Background()
+	apiKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, "x-api-key", key)
+	}
+
+	client := newGRPCTest(t, newGRPCLimiter(t, 1), limitergrpc.Config{
+		KeyGenerator: limitergrpc.MetadataKey("x-api-key"),
+		Headers:      limiter.HeadersNone,
+	}, &testService{})
+	var header metadata.MD
+	_, err := client.EmptyCall(apiKey("a"), &testpb.Empty{}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Empty(t, header.Get("x-ratelimit-limit"))
+	_, err = client.EmptyCall(apiKey("b"), &testpb.Empty{})
+	require.NoError(t, err)
+	_, err = client.EmptyCall(apiKey("a"), &testpb.Empty{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// One limit per method, whoever calls.
+	client = newGRPCTest(t, newGRPCLimiter(t, 1), limitergrpc.Config{KeyGenerator: limitergrpc.MethodKey}, &testService{})
+	_, err = client.EmptyCall(apiKey("a"), &testpb.Empty{})
+	require.NoError(t, err)
+	_, err = client.EmptyCall(apiKey("b"), &testpb.Empty{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	stream, err := client.StreamingInputCall(ctx)
+	require.NoError(t, err)
+	_, err = stream.CloseAndRecv()
+	require.NoError(t, err, "streams of another method have their own limit")
+}
+
+func TestGRPCSkipSuccessful(t *testing.T) {
+	svc := &testService{}
+	client := newGRPCTest(t, newGRPCLimiter(t, 1), limitergrpc.Config{Skipsuccessfull: true}, svc)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := client.EmptyCall(ctx, &testpb.Empty{})
+		require.NoError(t, err)
+	}
+	svc.fail = true
+	_, err := client.EmptyCall(ctx, &testpb.Empty{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	_, err = client.EmptyCall(ctx, &testpb.Empty{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestGRPCStreamInterceptor(t *testing.T) {
+	client := newGRPCTest(t, newGRPCLimiter(t, 1), limitergrpc.Config{}, &testService{})
+	ctx := context.Background()
+
+	stream, err := client.StreamingInputCall(ctx)
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, stream.Send(&testpb.StreamingInputCallRequest{}))
+	}
+	resp, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, int32(5), resp.GetAggregatedPayloadSize(), "a stream counts once")
+
+	stream, err = client.StreamingInputCall(ctx)
+	require.NoError(t, err)
+	_, err = stream.CloseAndRecv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestGRPCStreamPerMessage(t *testing.T) {
+	client := newGRPCTest(t, newGRPCLimiter(t, 3), limitergrpc.Config{PerMessage: true}, &testService{})
+	ctx := context.Background()
+
+	stream, err := client.StreamingInputCall(ctx)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, stream.Send(&testpb.StreamingInputCallRequest{}))
+	}
+	resp, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), resp.GetAggregatedPayloadSize())
+
+	stream, err = client.StreamingInputCall(ctx)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		if err := stream.Send(&testpb.StreamingInputCallRequest{}); err != nil {
+			break
+		}
+	}
+	_, err = stream.CloseAndRecv()
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code(), "the fourth message is over the limit")
+}
+
+func TestGRPCStoreError(t *testing.T) {
+	store := newFlakyStore()
+	store.failing.Store(true)
+	l, err := limiter.New(limiter.Config{
+		MaxRequests: 1,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+		Store:       store,
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	client := newGRPCTest(t, l, limitergrpc.Config{}, &testService{})
+	_, err = client.EmptyCall(context.Background(), &testpb.Empty{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "store down")
+}
@@ -0,0 +1,175 @@
+package limiter_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	limiterfiber "github.com/NarmadaWeb/limiter/fiber/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decisionRecorder collects decisions by hook name.
+type decisionRecorder struct {
+	mu        sync.Mutex
+	decisions map[string][]limiter.Decision
+}
+
+func (r *decisionRecorder) hooks() limiter.Hooks {
+	r.decisions = make(map[string][]limiter.Decision)
+	record := func(name string) func(limiter.Decision) {
+		return func(d limiter.Decision) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.decisions[name] = append(r.decisions[name], d)
+		}
+	}
+	return limiter.Hooks{
+		OnAllowed:  record("allowed"),
+		OnLimited:  record("limited"),
+		OnError:    record("error"),
+		OnFallback: record("fallback"),
+	}
+}
+
+func (r *decisionRecorder) get(name string) []limiter.Decision {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]limiter.Decision(nil), r.decisions[name]...)
+}
+
+func TestHooksStdLib(t *testing.T) {
+	var rec decisionRecorder
+	l, err := limiter.New(limiter.Config{
+		Name:        "api",
+		MaxRequests: 1,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+		Hooks:       rec.hooks(),
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	handler := l.StdLibMiddleware(limiter.StdLibConfig{})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+		r.Header.Set("User-Agent", "scanner/1.0")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	require.Len(t, rec.get("allowed"), 1)
+	limited := rec.get("limited")
+	require.Len(t, limited, 1)
+	d := limited[0]
+	assert.Equal(t, "api", d.Limiter)
+	assert.Equal(t, "192.0.2.1", d.Key)
+	assert.False(t, d.Allowed)
+	assert.Equal(t, 1, d.Limit)
+	assert.Equal(t, 0, d.Remaining)
+	assert.Equal(t, "1;w=60", d.Policy)
+	assert.Equal(t, limiter.RequestInfo{
+		Method:     http.MethodPost,
+		Path:       "/orders",
+		RemoteAddr: "192.0.2.1:1234",
+		UserAgent:  "scanner/1.0",
+	}, d.Request)
+	assert.Empty(t, rec.get("error"))
+}
+
+func TestHooksFiberDecisionOutlivesRequest(t *testing.T) {
+	decisions := make(chan limiter.Decision, 10)
+	l, err := limiter.New(limiter.Config{
+		MaxRequests: 5,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+		Hooks: limiter.Hooks{OnAllowed: func(d limiter.Decision) {
+			// Handed to another goroutine, as a log shipper would.
+			decisions <- d
+		}},
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	app := fiber.New()
+	app.Use(limiterfiber.New(l, limiterfiber.Config{
+		KeyGenerator: func(c *fiber.Ctx) string { return c.Get("X-Api-Key") },
+	}))
+	app.Get("/*", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	for _, path := range []string{"/first", "/second"} {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("X-Api-Key", "key"+path)
+		_, err := app.Test(r)
+		require.NoError(t, err)
+	}
+
+	first, second := <-decisions, <-decisions
+	assert.Equal(t, "key/first", first.Key)
+	assert.Equal(t, "/first", first.Request.Path)
+	assert.Equal(t, "key/second", second.Key)
+	assert.Equal(t, "/second", second.Request.Path)
+}
+
+func TestHooksErrorAndFallback(t *testing.T) {
+	var rec decisionRecorder
+	mr, handler := newFailureTestLimiter(t, limiter.Config{
+		MaxRequests:   5,
+		FailurePolicy: limiter.FailOpen,
+		Hooks:         rec.hooks(),
+	})
+	mr.Close()
+
+	assert.Equal(t, http.StatusOK, serve(handler))
+
+	errs := rec.get("error")
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0].Err, limiter.ErrRedisConnection)
+
+	fallbacks := rec.get("fallback")
+	require.Len(t, fallbacks, 1)
+	assert.True(t, fallbacks[0].Degraded)
+	assert.Equal(t, limiter.FailOpen, fallbacks[0].FailurePolicy)
+	assert.Len(t, rec.get("allowed"), 1)
+}
+
+func TestSlogHooks(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	l, err := limiter.New(limiter.Config{
+		Name:        "login",
+		MaxRequests: 1,
+		Window:      time.Minute,
+		Algorithm:   limiter.SlidingWindow,
+		Hooks:       limiter.SlogHooks(logger),
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	ctx := context.Background()
+	require.NoError(t, l.Allow(ctx, "alice"))
+	require.Error(t, l.Allow(ctx, "alice"))
+
+	// Allowed requests log at debug and are filtered out.
+	var entry struct {
+		Level    string
+		Msg      string
+		Decision map[string]any
+	}
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry))
+	assert.Equal(t, "WARN", entry.Level)
+	assert.Equal(t, "rate limit exceeded", entry.Msg)
+	assert.Equal(t, "login", entry.Decision["limiter"])
+	assert.Equal(t, "alice", entry.Decision["key"])
+	assert.Equal(t, false, entry.Decision["allowed"])
+	assert.NotContains(t, entry.Decision, "request")
+}
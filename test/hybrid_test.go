@@ -0,0 +1,152 @@
+package limiter_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	redisstore "github.com/NarmadaWeb/limiter/redis/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore counts the calls that reach a remote store.
+type countingStore struct {
+	limiter.Store
+	calls atomic.Int32
+}
+
+func (c *countingStore) Take(ctx context.Context, key string, maxRequests int, window time.Duration, algorithm limiter.Algorithm) (bool, int, time.Time, error) {
+	c.calls.Add(1)
+	return c.Store.Take(ctx, key, maxRequests, window, algorithm)
+}
+
+func (c *countingStore) TakeN(ctx context.Context, key string, n, maxRequests int, window time.Duration, algorithm limiter.Algorithm) (int, int, time.Time, error) {
+	c.calls.Add(1)
+	return c.Store.(limiter.BatchStore).TakeN(ctx, key, n, maxRequests, window, algorithm)
+}
+
+func TestHybridStoreLeasesBatches(t *testing.T) {
+	remote := &countingStore{Store: limiter.NewMemoryStore()}
+	store := limiter.NewHybridStore(remote, limiter.HybridStoreOptions{BatchSize: 10, LeaseTTL: time.Minute})
+	ctx := context.Background()
+
+	for i := 0; i < 25; i++ {
+		allowed, remaining, _, err := store.Take(ctx, "client", 100, time.Minute, "fixed-window")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, 100-i-1, remaining)
+	}
+	assert.Equal(t, int32(3), remote.calls.Load())
+}
+
+func TestHybridStoreCachesDenials(t *testing.T) {
+	remote := &countingStore{Store: limiter.NewMemoryStore()}
+	store := limiter.NewHybridStore(remote, limiter.HybridStoreOptions{BatchSize: 5, LeaseTTL: time.Minute})
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		allowed, _, _, err := store.Take(ctx, "abuser", 5, time.Minute, "fixed-window")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+
+	for i := 0; i < 100; i++ {
+		allowed, remaining, reset, err := store.Take(ctx, "abuser", 5, time.Minute, "fixed-window")
+		require.NoError(t, err)
+		assert.False(t, allowed)
+		assert.Equal(t, 0, remaining)
+		assert.True(t, reset.After(time.Now()))
+	}
+
+	// One lease plus one denied lease attempt; every later denial is local.
+	assert.Equal(t, int32(2), remote.calls.Load())
+}
+
+func TestHybridStoreWithoutBatchRemote(t *testing.T) {
+	remote := newFlakyStore()
+	// Hide MemoryStore.TakeN so the remote is not a BatchStore.
+	store := limiter.NewHybridStore(struct{ limiter.Store }{remote}, limiter.HybridStoreOptions{BatchSize: 10})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		allowed, _, _, err := store.Take(ctx, "client", 3, time.Minute, "fixed-window")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, _, _, err := store.Take(ctx, "client", 3, time.Minute, "fixed-window")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, int32(4), remote.calls.Load())
+}
+
+func TestHybridStoreRollbackReturnsToLease(t *testing.T) {
+	remote := &countingStore{Store: limiter.NewMemoryStore()}
+	store := limiter.NewHybridStore(remote, limiter.HybridStoreOptions{BatchSize: 2, LeaseTTL: time.Minute})
+	ctx := context.Background()
+
+	allowed, _, _, err := store.Take(ctx, "client", 10, time.Minute, "fixed-window")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	require.NoError(t, store.Rollback(ctx, "client"))
+
+	for i := 0; i < 2; i++ {
+		allowed, _, _, err = store.Take(ctx, "client", 10, time.Minute, "fixed-window")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	assert.Equal(t, int32(1), remote.calls.Load())
+}
+
+// admitConcurrently spreads attempts over several HybridStores sharing one
+// remote store and returns how many requests were admitted.
+func admitConcurrently(t *testing.T, remote limiter.Store, instances, attempts, maxRequests, batch int, algorithm limiter.Algorithm) int {
+	t.Helper()
+	var admitted atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < instances; i++ {
+		store := limiter.NewHybridStore(remote, limiter.HybridStoreOptions{BatchSize: batch, LeaseTTL: time.Minute})
+		for j := 0; j < attempts/instances; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				allowed, _, _, err := store.Take(context.Background(), "shared", maxRequests, time.Minute, algorithm)
+				assert.NoError(t, err)
+				if allowed {
+					admitted.Add(1)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	return int(admitted.Load())
+}
+
+func TestHybridStoreBoundedAdmissionFixedWindow(t *testing.T) {
+	const instances, maxRequests, batch = 4, 50, 10
+
+	admitted := admitConcurrently(t, limiter.NewMemoryStore(), instances, 1000, maxRequests, batch, "fixed-window")
+
+	// Fixed-window leases end at the window reset, so nothing is over-admitted.
+	assert.LessOrEqual(t, admitted, maxRequests)
+	assert.GreaterOrEqual(t, admitted, maxRequests-instances*(batch-1))
+}
+
+func TestHybridStoreBoundedAdmissionRedis(t *testing.T) {
+	const instances, maxRequests, batch = 4, 50, 10
+
+	for _, algorithm := range []limiter.Algorithm{limiter.TokenBucket, limiter.SlidingWindow, limiter.FixedWindow} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			_, client := newTestRedis(t)
+			remote := redisstore.NewStore(client)
+
+			admitted := admitConcurrently(t, remote, instances, 400, maxRequests, batch, algorithm)
+
+			assert.LessOrEqual(t, admitted, maxRequests+instances*(batch-1))
+			assert.GreaterOrEqual(t, admitted, maxRequests-instances*(batch-1))
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package limiter_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreShardsAreIndependent(t *testing.T) {
+	store := limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{Shards: 16})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				allowed, _, _, err := store.Take(ctx, key, 5, time.Minute, "fixed-window")
+				assert.NoError(t, err)
+				assert.Equal(t, j < 5, allowed)
+			}
+		}("client-" + strconv.Itoa(i))
+	}
+	wg.Wait()
+
+	assert.Equal(t, 100, store.Len())
+	count, err := store.Get(ctx, "client-42")
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+}
+
+func TestMemoryStoreExpiredEntryIsReset(t *testing.T) {
+	store := limiter.NewMemoryStore()
+	ctx := context.Background()
+
+	allowed, _, _, err := store.Take(ctx, "client", 1, 20*time.Millisecond, "fixed-window")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	time.Sleep(30 * time.Millisecond)
+
+	allowed, _, _, err = store.Take(ctx, "client", 1, 20*time.Millisecond, "fixed-window")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestMemoryStoreJanitor(t *testing.T) {
+	store := limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{CleanupInterval: 10 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store.StartJanitor(ctx)
+
+	for i := 0; i < 1000; i++ {
+		_, _, _, err := store.Take(ctx, "client-"+strconv.Itoa(i), 5, 5*time.Millisecond, "fixed-window")
+		require.NoError(t, err)
+	}
+	_, _, _, err := store.Take(ctx, "long-lived", 5, time.Minute, "fixed-window")
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return store.Len() == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestMemoryStoreJanitorBehindCircuitBreaker(t *testing.T) {
+	store := limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{CleanupInterval: 10 * time.Millisecond})
+	l, err := limiter.New(limiter.Config{
+		Store:          store,
+		MaxRequests:    5,
+		Window:         5 * time.Millisecond,
+		Algorithm:      "fixed-window",
+		CircuitBreaker: &limiter.CircuitBreakerOptions{},
+	})
+	require.NoError(t, err)
+	defer l.Close()
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, l.Allow(context.Background(), "client-"+strconv.Itoa(i)))
+	}
+
+	assert.Eventually(t, func() bool { return store.Len() == 0 }, time.Second, 5*time.Millisecond)
+}
+
+func TestMemoryStoreMaxKeys(t *testing.T) {
+	store := limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{Shards: 1, MaxKeys: 100})
+	ctx := context.Background()
+
+	for i := 0; i < 1000; i++ {
+		allowed, _, _, err := store.Take(ctx, "10.0.0."+strconv.Itoa(i), 5, time.Minute, "fixed-window")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+
+	stats := store.Stats()
+	assert.Equal(t, 100, stats.Keys)
+	assert.Equal(t, uint64(900), stats.Evictions)
+}
+
+func TestMemoryStoreMaxBytes(t *testing.T) {
+	store := limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{Shards: 4, MaxBytes: 64 << 10})
+	ctx := context.Background()
+
+	for i := 0; i < 10000; i++ {
+		_, _, _, err := store.Take(ctx, "session-"+strconv.Itoa(i), 5, time.Minute, "fixed-window")
+		require.NoError(t, err)
+	}
+
+	stats := store.Stats()
+	assert.LessOrEqual(t, stats.Bytes, 64<<10)
+	assert.Positive(t, stats.Evictions)
+}
+
+func TestMemoryStoreEvictLRU(t *testing.T) {
+	store := limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{Shards: 1, MaxKeys: 3, EvictionSamples: 3})
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b", "c"} {
+		_, _, _, err := store.Take(ctx, key, 5, time.Minute, "fixed-window")
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond)
+	}
+	_, _, _, err := store.Take(ctx, "a", 5, time.Minute, "fixed-window")
+	require.NoError(t, err)
+
+	_, _, _, err = store.Take(ctx, "d", 5, time.Minute, "fixed-window")
+	require.NoError(t, err)
+
+	count, err := store.Get(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count, "least recently used key should be evicted")
+	count, err = store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestMemoryStoreEvictMostRemaining(t *testing.T) {
+	store := limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{
+		Shards:          1,
+		MaxKeys:         3,
+		EvictionSamples: 3,
+		Eviction:        limiter.EvictMostRemaining,
+	})
+	ctx := context.Background()
+
+	uses := map[string]int{"exhausted": 5, "fresh": 1, "busy": 4}
+	for key, n := range uses {
+		for i := 0; i < n; i++ {
+			_, _, _, err := store.Take(ctx, key, 5, time.Minute, "fixed-window")
+			require.NoError(t, err)
+		}
+	}
+
+	_, _, _, err := store.Take(ctx, "newcomer", 5, time.Minute, "fixed-window")
+	require.NoError(t, err)
+
+	count, err := store.Get(ctx, "fresh")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count, "key with most remaining quota should be evicted")
+
+	allowed, _, _, err := store.Take(ctx, "exhausted", 5, time.Minute, "fixed-window")
+	require.NoError(t, err)
+	assert.False(t, allowed, "exhausted client must stay blocked")
+}
+
+func TestMemoryStoreTightensLimitsUnderPressure(t *testing.T) {
+	store := limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{
+		Shards:            1,
+		MaxKeys:           10,
+		PressureThreshold: 0.5,
+		PressureScale:     0.5,
+	})
+	ctx := context.Background()
+
+	admitted := func(key string) int {
+		n := 0
+		for i := 0; i < 10; i++ {
+			allowed, _, _, err := store.Take(ctx, key, 10, time.Minute, "fixed-window")
+			require.NoError(t, err)
+			if allowed {
+				n++
+			}
+		}
+		return n
+	}
+
+	assert.Equal(t, 10, admitted("calm"))
+	for i := 0; i < 5; i++ {
+		_, _, _, err := store.Take(ctx, "filler-"+strconv.Itoa(i), 10, time.Minute, "fixed-window")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 5, admitted("pressured"))
+}
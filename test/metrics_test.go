@@ -0,0 +1,164 @@
+package limiter_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	limiterfiber "github.com/NarmadaWeb/limiter/fiber/v3"
+	"github.com/NarmadaWeb/limiter/metrics/v3"
+	redisstore "github.com/NarmadaWeb/limiter/redis/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsStdLib(t *testing.T) {
+	collector := metrics.NewCollector()
+	require.NoError(t, prometheus.NewPedanticRegistry().Register(collector))
+
+	l, err := limiter.New(limiter.Config{
+		Name:        "api",
+		MaxRequests: 2,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+		Observer:    collector,
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	handler := l.StdLibMiddleware(limiter.StdLibConfig{Skipsuccessfull: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	for _, path := range []string{"/", "/fail", "/fail", "/fail"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	expected := `
+# HELP limiter_memory_store_keys Keys held by the limiter's MemoryStore.
+# TYPE limiter_memory_store_keys gauge
+limiter_memory_store_keys{limiter="api"} 1
+# HELP limiter_requests_total Requests seen by the limiter, by result (allowed or denied). Mode is enforced, dry_run or shadow.
+# TYPE limiter_requests_total counter
+limiter_requests_total{limiter="api",mode="enforced",policy="2;w=60",result="allowed",shadow=""} 3
+limiter_requests_total{limiter="api",mode="enforced",policy="2;w=60",result="denied",shadow=""} 1
+# HELP limiter_rollbacks_total Requests returned to the store, e.g. by Skipsuccessfull. Shadow limits are not counted.
+# TYPE limiter_rollbacks_total counter
+limiter_rollbacks_total{limiter="api"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"limiter_memory_store_keys", "limiter_requests_total", "limiter_rollbacks_total"))
+	assert.Equal(t, 1, testutil.CollectAndCount(collector, "limiter_store_take_duration_seconds"))
+}
+
+func TestMetricsFiberFailOpen(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1, DialerRetries: 1})
+	t.Cleanup(func() { _ = client.Close() })
+
+	collector := metrics.NewCollector()
+	l, err := limiter.New(limiter.Config{
+		Name:          "fiber",
+		Store:         redisstore.NewStore(client),
+		MaxRequests:   5,
+		Window:        time.Minute,
+		Algorithm:     limiter.FixedWindow,
+		FailurePolicy: limiter.FailOpen,
+		Observer:      collector,
+	})
+	require.NoError(t, err)
+
+	app := fiber.New()
+	app.Use(limiterfiber.New(l, limiterfiber.Config{}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	mr.Close()
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	expected := `
+# HELP limiter_fail_open_total Requests admitted by FailOpen while the store was failing.
+# TYPE limiter_fail_open_total counter
+limiter_fail_open_total{limiter="fiber"} 1
+# HELP limiter_requests_total Requests seen by the limiter, by result (allowed or denied). Mode is enforced, dry_run or shadow.
+# TYPE limiter_requests_total counter
+limiter_requests_total{limiter="fiber",mode="enforced",policy="5;w=60",result="allowed",shadow=""} 2
+# HELP limiter_store_errors_total Store.Take calls that returned an error.
+# TYPE limiter_store_errors_total counter
+limiter_store_errors_total{limiter="fiber",store="redis"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"limiter_fail_open_total", "limiter_requests_total", "limiter_store_errors_total"))
+	assert.Equal(t, 0, testutil.CollectAndCount(collector, "limiter_memory_store_keys"))
+}
+
+func TestMetricsMemoryStoreBehindCircuitBreaker(t *testing.T) {
+	collector := metrics.NewCollector()
+	l, err := limiter.New(limiter.Config{
+		Name:           "api",
+		MaxRequests:    2,
+		Window:         time.Minute,
+		Algorithm:      limiter.FixedWindow,
+		CircuitBreaker: &limiter.CircuitBreakerOptions{},
+		Observer:       collector,
+	})
+	require.NoError(t, err)
+	require.NoError(t, l.Allow(context.Background(), "client"))
+
+	expected := `
+# HELP limiter_memory_store_keys Keys held by the limiter's MemoryStore.
+# TYPE limiter_memory_store_keys gauge
+limiter_memory_store_keys{limiter="api"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), "limiter_memory_store_keys"))
+
+	require.NoError(t, l.Close())
+	assert.Equal(t, 0, testutil.CollectAndCount(collector, "limiter_memory_store_keys"), "a closed limiter is forgotten")
+}
+
+func TestMetricsMemoryStoreEvictions(t *testing.T) {
+	collector := metrics.NewCollector()
+	l, err := limiter.New(limiter.Config{
+		Name:        "api",
+		Store:       limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{Shards: 1, MaxKeys: 1}),
+		MaxRequests: 2,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+		Observer:    collector,
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	for _, key := range []string{"a", "b", "c"} {
+		require.NoError(t, l.Allow(context.Background(), key))
+	}
+
+	expected := `
+# HELP limiter_memory_store_evictions_total Keys evicted from the limiter's MemoryStore to respect MaxKeys or MaxBytes.
+# TYPE limiter_memory_store_evictions_total counter
+limiter_memory_store_evictions_total{limiter="api"} 2
+# HELP limiter_memory_store_keys Keys held by the limiter's MemoryStore.
+# TYPE limiter_memory_store_keys gauge
+limiter_memory_store_keys{limiter="api"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"limiter_memory_store_evictions_total", "limiter_memory_store_keys"))
+	assert.Equal(t, 1, testutil.CollectAndCount(collector, "limiter_memory_store_bytes"))
+}
@@ -6,7 +6,8 @@ import (
 	"testing"
 	"time"
 
-	"github.com/NarmadaWeb/limiter/v2"
+	limiterfiber "github.com/NarmadaWeb/limiter/fiber/v3"
+	"github.com/NarmadaWeb/limiter/v3"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 )
@@ -24,7 +25,7 @@ func TestLimiterMiddleware(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Use FiberMiddleware with empty config (defaults)
-	app.Use(l.FiberMiddleware(limiter.FiberConfig{}))
+	app.Use(limiterfiber.New(l, limiterfiber.Config{}))
 
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.SendString("OK")
@@ -0,0 +1,185 @@
+package limiter_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	limiterecho "github.com/NarmadaWeb/limiter/echo/v3"
+	limiterfiber "github.com/NarmadaWeb/limiter/fiber/v3"
+	limitergin "github.com/NarmadaWeb/limiter/gin/v3"
+	limiterotel "github.com/NarmadaWeb/limiter/otel/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type otelHarness struct {
+	spans    *tracetest.InMemoryExporter
+	tracer   trace.Tracer
+	reader   *sdkmetric.ManualReader
+	observer *limiterotel.Observer
+}
+
+func newOtelHarness(t *testing.T) *otelHarness {
+	t.Helper()
+	spans := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	observer, err := limiterotel.NewObserverWithOptions(limiterotel.Options{TracerProvider: tp, MeterProvider: mp})
+	require.NoError(t, err)
+	return &otelHarness{spans: spans, tracer: tp.Tracer("server"), reader: reader, observer: observer}
+}
+
+// serverSpan starts the span an HTTP server instrumentation would.
+func (h *otelHarness) serverSpan() (context.Context, trace.Span) {
+	return h.tracer.Start(context.Background(), "GET /", trace.WithSpanKind(trace.SpanKindServer))
+}
+
+// takeSpan returns the only limiter.Take span recorded.
+func (h *otelHarness) takeSpan(t *testing.T) tracetest.SpanStub {
+	t.Helper()
+	var found []tracetest.SpanStub
+	for _, s := range h.spans.GetSpans() {
+		if s.Name == "limiter.Take" {
+			found = append(found, s)
+		}
+	}
+	require.Len(t, found, 1)
+	return found[0]
+}
+
+// counter sums the data points of an Int64 counter matching attr.
+func (h *otelHarness) counter(t *testing.T, name string, attr attribute.KeyValue) int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, h.reader.Collect(context.Background(), &rm))
+
+	var total int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if m.Name != name || !ok {
+				continue
+			}
+			for _, dp := range sum.DataPoints {
+				if v, ok := dp.Attributes.Value(attr.Key); ok && v == attr.Value {
+					total += dp.Value
+				}
+			}
+		}
+	}
+	return total
+}
+
+func newOtelLimiter(t *testing.T, observer limiter.Observer) *limiter.Limiter {
+	t.Helper()
+	l, err := limiter.New(limiter.Config{
+		Name:        "api",
+		MaxRequests: 1,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+		Observer:    observer,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+func TestOtelSpansNestUnderServerSpan(t *testing.T) {
+	adapters := map[string]func(l *limiter.Limiter, ctx context.Context) int{
+		"stdlib": func(l *limiter.Limiter, ctx context.Context) int {
+			handler := l.StdLibMiddleware(limiter.StdLibConfig{})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+			return w.Code
+		},
+		"gin": func(l *limiter.Limiter, ctx context.Context) int {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(limitergin.New(l, limitergin.Config{}))
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+			return w.Code
+		},
+		"echo": func(l *limiter.Limiter, ctx context.Context) int {
+			e := echo.New()
+			e.Use(limiterecho.New(l, limiterecho.Config{}))
+			e.GET("/", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+			return w.Code
+		},
+		"fiber": func(l *limiter.Limiter, ctx context.Context) int {
+			app := fiber.New()
+			// otelfiber stores the server span in the user context.
+			app.Use(func(c *fiber.Ctx) error {
+				c.SetUserContext(ctx)
+				return c.Next()
+			})
+			app.Use(limiterfiber.New(l, limiterfiber.Config{}))
+			app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(http.StatusOK) })
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+			require.NoError(t, err)
+			return resp.StatusCode
+		},
+	}
+
+	for name, serve := range adapters {
+		t.Run(name, func(t *testing.T) {
+			h := newOtelHarness(t)
+			l := newOtelLimiter(t, h.observer)
+
+			ctx, server := h.serverSpan()
+			assert.Equal(t, http.StatusOK, serve(l, ctx))
+			server.End()
+
+			span := h.takeSpan(t)
+			assert.Equal(t, server.SpanContext().SpanID(), span.Parent.SpanID())
+			assert.Equal(t, server.SpanContext().TraceID(), span.SpanContext.TraceID())
+			assert.Contains(t, span.Attributes, limiterotel.AlgorithmKey.String("fixed-window"))
+			assert.Contains(t, span.Attributes, limiterotel.StoreKey.String("memory"))
+			assert.Contains(t, span.Attributes, limiterotel.AllowedKey.Bool(true))
+			assert.Contains(t, span.Attributes, limiterotel.RemainingKey.Int(0))
+		})
+	}
+}
+
+func TestOtelMetrics(t *testing.T) {
+	h := newOtelHarness(t)
+	l := newOtelLimiter(t, h.observer)
+	ctx := context.Background()
+
+	require.NoError(t, l.Allow(ctx, "client"))
+	require.ErrorIs(t, l.Allow(ctx, "client"), limiter.ErrLimitExceeded)
+
+	assert.Equal(t, int64(1), h.counter(t, "limiter.requests", limiterotel.ResultKey.String("allowed")))
+	assert.Equal(t, int64(1), h.counter(t, "limiter.requests", limiterotel.ResultKey.String("denied")))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, h.reader.Collect(ctx, &rm))
+	names := map[string]bool{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			names[m.Name] = true
+		}
+	}
+	assert.True(t, names["limiter.store.take.duration"])
+	assert.True(t, names["limiter.memory_store.keys"])
+	assert.True(t, names["limiter.memory_store.size"])
+	assert.True(t, names["limiter.memory_store.evictions"])
+}
@@ -0,0 +1,263 @@
+package limiter_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	redisstore "github.com/NarmadaWeb/limiter/redis/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return mr, client
+}
+
+func TestRedisStoreSubSecondWindow(t *testing.T) {
+	for _, algorithm := range []limiter.Algorithm{limiter.TokenBucket, limiter.SlidingWindow, limiter.FixedWindow} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			mr, client := newTestRedis(t)
+			store := redisstore.NewStoreWithOptions(client, redisstore.StoreOptions{UseServerTime: true})
+			ctx := context.Background()
+			window := 250 * time.Millisecond
+
+			start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			mr.SetTime(start)
+
+			for i := 0; i < 2; i++ {
+				allowed, remaining, reset, err := store.Take(ctx, "client", 2, window, algorithm)
+				require.NoError(t, err)
+				assert.True(t, allowed)
+				assert.Equal(t, 1-i, remaining)
+				assert.False(t, reset.After(start.Add(window)), "reset %v should be within the window", reset)
+			}
+
+			allowed, _, reset, err := store.Take(ctx, "client", 2, window, algorithm)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+			assert.True(t, reset.After(start), "reset %v should be in the future", reset)
+			assert.False(t, reset.After(start.Add(window)), "reset %v should be within the window", reset)
+
+			mr.SetTime(start.Add(window))
+			mr.FastForward(window)
+
+			allowed, _, _, err = store.Take(ctx, "client", 2, window, algorithm)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+		})
+	}
+}
+
+func TestRedisStoreMillisecondReset(t *testing.T) {
+	mr, client := newTestRedis(t)
+	store := redisstore.NewStoreWithOptions(client, redisstore.StoreOptions{UseServerTime: true})
+	ctx := context.Background()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mr.SetTime(start)
+
+	_, _, reset, err := store.Take(ctx, "client", 5, 250*time.Millisecond, "fixed-window")
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(250*time.Millisecond).UnixMilli(), reset.UnixMilli())
+
+	_, _, reset, err = store.Take(ctx, "client", 5, 250*time.Millisecond, "sliding-window")
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(250*time.Millisecond).UnixMilli(), reset.UnixMilli())
+}
+
+func TestRedisStoreServerTimeIgnoresClientClock(t *testing.T) {
+	mr, client := newTestRedis(t)
+	store := redisstore.NewStoreWithOptions(client, redisstore.StoreOptions{UseServerTime: true})
+	ctx := context.Background()
+
+	// The Redis clock lives far in the past; all decisions must follow it.
+	serverNow := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	mr.SetTime(serverNow)
+
+	_, _, reset, err := store.Take(ctx, "client", 5, time.Second, "token-bucket")
+	require.NoError(t, err)
+	assert.True(t, reset.Before(serverNow.Add(2*time.Second)), "reset %v should follow the server clock", reset)
+}
+
+func TestRedisStoreSlidingWindowClientClock(t *testing.T) {
+	_, client := newTestRedis(t)
+	store := redisstore.NewStore(client)
+	ctx := context.Background()
+	window := 250 * time.Millisecond
+
+	for i := 0; i < 3; i++ {
+		allowed, _, _, err := store.Take(ctx, "client", 3, window, "sliding-window")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+
+	allowed, _, _, err := store.Take(ctx, "client", 3, window, "sliding-window")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	time.Sleep(window + 50*time.Millisecond)
+
+	allowed, _, _, err = store.Take(ctx, "client", 3, window, "sliding-window")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestLimiterWithRedisStoreOptions(t *testing.T) {
+	mr, client := newTestRedis(t)
+	mr.SetTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	l, err := limiter.New(limiter.Config{
+		Store:       redisstore.NewStoreWithOptions(client, redisstore.StoreOptions{UseServerTime: true}),
+		MaxRequests: 1,
+		Window:      100 * time.Millisecond,
+		Algorithm:   "fixed-window",
+	})
+	require.NoError(t, err)
+
+	handler := l.StdLibMiddleware(limiter.StdLibConfig{})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	codes := make([]int, 0, 2)
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		codes = append(codes, w.Code)
+	}
+	assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests}, codes)
+}
+
+func TestRedisStoreKeyLayout(t *testing.T) {
+	mr, client := newTestRedis(t)
+	ctx := context.Background()
+
+	_, _, _, err := redisstore.NewStore(client).Take(ctx, "1.2.3.4", 5, time.Minute, "fixed-window")
+	require.NoError(t, err)
+	assert.True(t, mr.Exists("rate_limit:fixed-window:1.2.3.4"))
+
+	store := redisstore.NewStoreWithOptions(client, redisstore.StoreOptions{
+		Prefix:    "rl:",
+		Namespace: "billing",
+		Name:      "api",
+	})
+	_, _, _, err = store.Take(ctx, "1.2.3.4", 5, time.Minute, "fixed-window")
+	require.NoError(t, err)
+	assert.True(t, mr.Exists("rl:billing:api:fixed-window:1.2.3.4"))
+}
+
+func TestRedisStoreKeyHashing(t *testing.T) {
+	for name, hash := range map[string]redisstore.KeyHash{"sha1": redisstore.KeyHashSHA1, "xxhash": redisstore.KeyHashXXHash} {
+		t.Run(name, func(t *testing.T) {
+			mr, client := newTestRedis(t)
+			ctx := context.Background()
+			store := redisstore.NewStoreWithOptions(client, redisstore.StoreOptions{KeyHash: hash})
+
+			_, _, _, err := store.Take(ctx, "user@example.com", 5, time.Minute, "fixed-window")
+			require.NoError(t, err)
+
+			keys := mr.Keys()
+			require.Len(t, keys, 1)
+			assert.NotContains(t, keys[0], "user@example.com")
+
+			count, err := store.Get(ctx, "user@example.com")
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+		})
+	}
+}
+
+func TestRedisStoreNamedLimitersDoNotShareCounters(t *testing.T) {
+	_, client := newTestRedis(t)
+	ctx := context.Background()
+
+	newLimiterStore := func(name string) limiter.Store {
+		return redisstore.NewStoreWithOptions(client, redisstore.StoreOptions{Namespace: "svc", Name: name})
+	}
+	login := newLimiterStore("login")
+	search := newLimiterStore("search")
+
+	allowed, _, _, err := login.Take(ctx, "client", 1, time.Minute, "fixed-window")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, _, _, err = login.Take(ctx, "client", 1, time.Minute, "fixed-window")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	allowed, _, _, err = search.Take(ctx, "client", 1, time.Minute, "fixed-window")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestStoreNameIsEmbeddedInRedisKeys(t *testing.T) {
+	mr, client := newTestRedis(t)
+
+	l, err := limiter.New(limiter.Config{
+		Name:        "checkout",
+		Store:       redisstore.NewStoreWithOptions(client, redisstore.StoreOptions{Name: "checkout"}),
+		MaxRequests: 5,
+		Window:      time.Minute,
+		Algorithm:   "fixed-window",
+	})
+	require.NoError(t, err)
+
+	handler := l.StdLibMiddleware(limiter.StdLibConfig{
+		KeyGenerator: func(_ *http.Request) string { return "client" },
+	})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.True(t, mr.Exists("rate_limit:checkout:fixed-window:client"))
+}
+
+func TestConfigNameSeparatesLimitersSharingARedisStore(t *testing.T) {
+	mr, client := newTestRedis(t)
+	store := redisstore.NewStore(client)
+	ctx := context.Background()
+
+	newNamedLimiter := func(name string) *limiter.Limiter {
+		l, err := limiter.New(limiter.Config{
+			Name:        name,
+			Store:       store,
+			MaxRequests: 1,
+			Window:      time.Minute,
+			Algorithm:   "fixed-window",
+		})
+		require.NoError(t, err)
+		return l
+	}
+	login := newNamedLimiter("login")
+	search := newNamedLimiter("search")
+
+	require.NoError(t, login.Allow(ctx, "client"))
+	assert.ErrorIs(t, login.Allow(ctx, "client"), limiter.ErrLimitExceeded)
+	require.NoError(t, search.Allow(ctx, "client"))
+
+	assert.True(t, mr.Exists("rate_limit:login:fixed-window:client"))
+	assert.True(t, mr.Exists("rate_limit:search:fixed-window:client"))
+}
+
+func TestStoreOptionsNameOverridesConfigName(t *testing.T) {
+	mr, client := newTestRedis(t)
+
+	l, err := limiter.New(limiter.Config{
+		Name:        "api",
+		Store:       redisstore.NewStoreWithOptions(client, redisstore.StoreOptions{Name: "checkout"}),
+		MaxRequests: 5,
+		Window:      time.Minute,
+		Algorithm:   "fixed-window",
+	})
+	require.NoError(t, err)
+	require.NoError(t, l.Allow(context.Background(), "client"))
+
+	assert.True(t, mr.Exists("rate_limit:checkout:fixed-window:client"))
+}
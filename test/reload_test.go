@@ -0,0 +1,282 @@
+package limiter_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	redisstore "github.com/NarmadaWeb/limiter/redis/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func allowN(l *limiter.Limiter, key string, n int) int {
+	admitted := 0
+	for i := 0; i < n; i++ {
+		if l.Allow(context.Background(), key) == nil {
+			admitted++
+		}
+	}
+	return admitted
+}
+
+func TestUpdateConfigKeepsCounters(t *testing.T) {
+	l, err := limiter.New(limiter.Config{
+		MaxRequests: 2,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	assert.Equal(t, 2, allowN(l, "alice", 3))
+
+	cfg := l.Config()
+	cfg.MaxRequests = 5
+	require.NoError(t, l.UpdateConfig(cfg))
+
+	// The two requests already counted still apply.
+	assert.Equal(t, 3, allowN(l, "alice", 5))
+	assert.Equal(t, 5, l.Config().MaxRequests)
+}
+
+func TestUpdateConfigRejectsInvalidConfig(t *testing.T) {
+	l, err := limiter.New(limiter.Config{
+		MaxRequests: 2,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	cfg := l.Config()
+	cfg.MaxRequests = 0
+	cfg.Algorithm = "nope"
+	err = l.UpdateConfig(cfg)
+	require.ErrorIs(t, err, limiter.ErrInvalidConfig)
+	assert.ErrorIs(t, err, limiter.ErrInvalidAlgorithm)
+	assert.Equal(t, 2, l.Config().MaxRequests)
+	assert.Equal(t, limiter.FixedWindow, l.Config().Algorithm)
+}
+
+func TestUpdateConfigIgnoresStoreSettings(t *testing.T) {
+	store := limiter.NewMemoryStore()
+	l, err := limiter.New(limiter.Config{
+		Name:        "api",
+		Store:       store,
+		MaxRequests: 2,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	require.NoError(t, l.UpdateConfig(limiter.Config{
+		Name:        "other",
+		Store:       limiter.NewMemoryStore(),
+		MaxRequests: 3,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+	}))
+	assert.Equal(t, "api", l.Config().Name)
+	assert.Same(t, store, l.Config().Store)
+
+	require.NoError(t, l.Allow(context.Background(), "alice"))
+	count, err := store.Get(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestUpdateConfigConcurrentTake(t *testing.T) {
+	l, err := limiter.New(limiter.Config{
+		MaxRequests: 1000,
+		Window:      time.Minute,
+		Algorithm:   limiter.SlidingWindow,
+		Shadow:      []limiter.ShadowLimit{{Name: "strict", MaxRequests: 10, Window: time.Minute}},
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			allowN(l, "alice", 50)
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		cfg := l.Config()
+		cfg.MaxRequests = 1000 + i
+		cfg.DryRun = i%2 == 0
+		cfg.Shadow = cfg.Shadow[:i%2]
+		require.NoError(t, l.UpdateConfig(cfg))
+	}
+	wg.Wait()
+}
+
+func TestUpdateConfigMigratesAlgorithm(t *testing.T) {
+	for _, tc := range []struct {
+		from, to limiter.Algorithm
+	}{
+		{limiter.FixedWindow, limiter.SlidingWindow},
+		{limiter.SlidingWindow, limiter.TokenBucket},
+		{limiter.TokenBucket, limiter.FixedWindow},
+	} {
+		t.Run(string(tc.from)+"/"+string(tc.to), func(t *testing.T) {
+			t.Run("memory", func(t *testing.T) {
+				testMigration(t, limiter.Config{}, tc.from, tc.to)
+			})
+			t.Run("redis", func(t *testing.T) {
+				mr := miniredis.RunT(t)
+				client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+				t.Cleanup(func() { _ = client.Close() })
+				testMigration(t, limiter.Config{Store: redisstore.NewStore(client)}, tc.from, tc.to)
+			})
+		})
+	}
+}
+
+func testMigration(t *testing.T, cfg limiter.Config, from, to limiter.Algorithm) {
+	t.Helper()
+	cfg.MaxRequests = 5
+	cfg.Window = time.Minute
+	cfg.Algorithm = from
+	l, err := limiter.New(cfg)
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	assert.Equal(t, 3, allowN(l, "alice", 3))
+
+	cfg = l.Config()
+	cfg.Algorithm = to
+	require.NoError(t, l.UpdateConfig(cfg))
+
+	// alice keeps her three requests, bob starts fresh.
+	assert.Equal(t, 2, allowN(l, "alice", 5))
+	assert.Equal(t, 5, allowN(l, "bob", 6))
+}
+
+func TestApplyJSON(t *testing.T) {
+	cfg := limiter.Config{MaxRequests: 5, Window: time.Minute, Algorithm: limiter.FixedWindow}
+	require.NoError(t, limiter.ApplyJSON([]byte(`{
+		"max_requests": 50,
+		"window": "30s",
+		"failure_policy": "fail-open",
+		"shadow": [{"name": "strict", "max_requests": 10, "window": "1m"}]
+	}`), &cfg))
+	assert.Equal(t, 50, cfg.MaxRequests)
+	assert.Equal(t, 30*time.Second, cfg.Window)
+	assert.Equal(t, limiter.FixedWindow, cfg.Algorithm)
+	assert.Equal(t, limiter.FailOpen, cfg.FailurePolicy)
+	assert.Equal(t, []limiter.ShadowLimit{{Name: "strict", MaxRequests: 10, Window: time.Minute}}, cfg.Shadow)
+
+	before := cfg
+	err := limiter.ApplyJSON([]byte(`{"window": "soon", "failure_policy": "maybe"}`), &cfg)
+	var cfgErr *limiter.ConfigError
+	require.ErrorAs(t, err, &cfgErr)
+	assert.Len(t, cfgErr.Fields, 2)
+	assert.Equal(t, before, cfg)
+
+	require.ErrorIs(t, limiter.ApplyJSON([]byte(`{"max_request": 1}`), &cfg), limiter.ErrInvalidConfig)
+}
+
+func TestWatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limits.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"max_requests": 2}`), 0o600))
+
+	l, err := limiter.New(limiter.Config{
+		MaxRequests: 1,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	errs := make(chan error, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, l.WatchFile(ctx, path, limiter.WatchOptions{
+		Interval: 5 * time.Millisecond,
+		OnError:  func(err error) { errs <- err },
+	}))
+	assert.Equal(t, 2, l.Config().MaxRequests)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"max_requests": 10, "dry_run": true}`), 0o600))
+	require.Eventually(t, func() bool { return l.Config().MaxRequests == 10 }, time.Second, 5*time.Millisecond)
+	assert.True(t, l.Config().DryRun)
+
+	// A bad document is reported and the configuration is kept.
+	require.NoError(t, os.WriteFile(path, []byte(`{"max_requests": -1}`), 0o600))
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, limiter.ErrInvalidConfig)
+	case <-time.After(time.Second):
+		t.Fatal("invalid document was not reported")
+	}
+	assert.Equal(t, 10, l.Config().MaxRequests)
+
+	// Fields removed from the file go back to their original value.
+	require.NoError(t, os.WriteFile(path, []byte(`{}`), 0o600))
+	require.Eventually(t, func() bool { return l.Config().MaxRequests == 1 }, time.Second, 5*time.Millisecond)
+	assert.False(t, l.Config().DryRun)
+}
+
+func TestWatchFileMissing(t *testing.T) {
+	l, err := limiter.New(limiter.Config{MaxRequests: 1, Window: time.Minute, Algorithm: limiter.FixedWindow})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	err = l.WatchFile(context.Background(), filepath.Join(t.TempDir(), "missing.json"), limiter.WatchOptions{})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestWatchRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer func() { _ = client.Close() }()
+
+	l, err := limiter.New(limiter.Config{
+		Store:       redisstore.NewStore(client),
+		MaxRequests: 1,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	reloads := make(chan limiter.Config, 10)
+	errs := make(chan error, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, redisstore.Watch(ctx, l, client, "limiter:config", limiter.WatchOptions{
+		OnReload: func(cfg limiter.Config) { reloads <- cfg },
+		OnError:  func(err error) { errs <- err },
+	}))
+
+	assert.Equal(t, 1, allowN(l, "alice", 2))
+
+	require.NoError(t, client.Publish(ctx, "limiter:config", `{"max_requests": 3}`).Err())
+	select {
+	case cfg := <-reloads:
+		assert.Equal(t, 3, cfg.MaxRequests)
+	case <-time.After(time.Second):
+		t.Fatal("no reload")
+	}
+	assert.Equal(t, 2, allowN(l, "alice", 3))
+
+	require.NoError(t, client.Publish(ctx, "limiter:config", `not json`).Err())
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, limiter.ErrInvalidConfig)
+	case <-time.After(time.Second):
+		t.Fatal("invalid message was not reported")
+	}
+	assert.Equal(t, 3, l.Config().MaxRequests)
+}
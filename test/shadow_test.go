@@ -0,0 +1,204 @@
+package limiter_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NarmadaWeb/limiter/metrics/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunNeverRejects(t *testing.T) {
+	var rec decisionRecorder
+	l, err := limiter.New(limiter.Config{
+		MaxRequests: 1,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+		DryRun:      true,
+		Hooks:       rec.hooks(),
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	handler := l.StdLibMiddleware(limiter.StdLibConfig{})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+		assert.Empty(t, w.Header().Get("Retry-After"))
+	}
+
+	assert.Len(t, rec.get("allowed"), 1)
+	limited := rec.get("limited")
+	require.Len(t, limited, 2)
+	assert.True(t, limited[0].DryRun)
+	assert.False(t, limited[0].Allowed)
+
+	require.NoError(t, l.Allow(context.Background(), "192.0.2.1"))
+}
+
+func TestDryRunRollbackSkipsDeniedRequests(t *testing.T) {
+	store := limiter.NewMemoryStore()
+	l, err := limiter.New(limiter.Config{
+		Store:       store,
+		MaxRequests: 2,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+		DryRun:      true,
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	handler := l.StdLibMiddleware(limiter.StdLibConfig{Skipsuccessfull: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	for _, path := range []string{"/fail", "/fail", "/fail", "/ok"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// The denied /ok was never counted, so it must not free a slot.
+	count, err := store.Get(context.Background(), "192.0.2.1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestShadowLimit(t *testing.T) {
+	var rec decisionRecorder
+	store := limiter.NewMemoryStore()
+	l, err := limiter.New(limiter.Config{
+		Name:        "api",
+		Store:       store,
+		MaxRequests: 10,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+		Hooks:       rec.hooks(),
+		Shadow: []limiter.ShadowLimit{
+			{Name: "strict", MaxRequests: 1, Window: 30 * time.Second},
+		},
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	ctx := context.Background()
+	require.NoError(t, l.Allow(ctx, "alice"))
+	require.NoError(t, l.Allow(ctx, "alice"))
+
+	limited := rec.get("limited")
+	require.Len(t, limited, 1)
+	d := limited[0]
+	assert.Equal(t, "strict", d.Shadow)
+	assert.Equal(t, "alice", d.Key)
+	assert.Equal(t, "1;w=30", d.Policy)
+	assert.Equal(t, limiter.FixedWindow, d.Algorithm)
+
+	allowed := rec.get("allowed")
+	require.Len(t, allowed, 3)
+	assert.Empty(t, allowed[0].Shadow)
+	assert.Equal(t, "strict", allowed[1].Shadow)
+
+	// The shadow counts apart from the enforced limit.
+	count, err := store.Get(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestShadowLimitRollback(t *testing.T) {
+	store := limiter.NewMemoryStore()
+	l, err := limiter.New(limiter.Config{
+		Store:       store,
+		MaxRequests: 10,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+		Shadow: []limiter.ShadowLimit{
+			{Name: "strict", MaxRequests: 1, Window: time.Minute},
+		},
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	handler := l.StdLibMiddleware(limiter.StdLibConfig{Skipsuccessfull: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	for _, path := range []string{"/fail", "/ok", "/ok"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	ctx := context.Background()
+	count, err := store.Get(ctx, "192.0.2.1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// The denied /ok requests must not free the shadow slot taken by /fail.
+	count, err = store.Get(ctx, "shadow:strict:192.0.2.1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestShadowLimitMetrics(t *testing.T) {
+	collector := metrics.NewCollector()
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, reg.Register(collector))
+
+	l, err := limiter.New(limiter.Config{
+		Name:        "api",
+		MaxRequests: 5,
+		Window:      time.Minute,
+		Algorithm:   limiter.SlidingWindow,
+		Observer:    collector,
+		Shadow: []limiter.ShadowLimit{
+			{Name: "strict", MaxRequests: 1, Window: time.Minute, Algorithm: limiter.TokenBucket},
+		},
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	ctx := context.Background()
+	require.NoError(t, l.Allow(ctx, "alice"))
+	require.NoError(t, l.Allow(ctx, "alice"))
+
+	expected := `
+# HELP limiter_requests_total Requests seen by the limiter, by result (allowed or denied). Mode is enforced, dry_run or shadow.
+# TYPE limiter_requests_total counter
+limiter_requests_total{limiter="api",mode="enforced",policy="5;w=60",result="allowed",shadow=""} 2
+limiter_requests_total{limiter="api",mode="shadow",policy="1;w=60",result="allowed",shadow="strict"} 1
+limiter_requests_total{limiter="api",mode="shadow",policy="1;w=60",result="denied",shadow="strict"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "limiter_requests_total"))
+}
+
+func TestShadowLimitValidation(t *testing.T) {
+	_, err := limiter.New(limiter.Config{
+		MaxRequests: 5,
+		Window:      time.Minute,
+		Algorithm:   limiter.FixedWindow,
+		Shadow: []limiter.ShadowLimit{
+			{Name: "a", MaxRequests: 1, Window: time.Minute},
+			{Name: "a", Window: time.Minute, Algorithm: "nope"},
+			{MaxRequests: 1},
+		},
+	})
+	var cfgErr *limiter.ConfigError
+	require.ErrorAs(t, err, &cfgErr)
+	var fields []string
+	for _, f := range cfgErr.Fields {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(t, []string{
+		"Shadow[1].Name", "Shadow[1].MaxRequests", "Shadow[1].Algorithm",
+		"Shadow[2].Name", "Shadow[2].Window",
+	}, fields)
+	assert.ErrorIs(t, err, limiter.ErrInvalidAlgorithm)
+}
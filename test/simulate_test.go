@@ -0,0 +1,119 @@
+package limiter_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/NarmadaWeb/limiter/v3/simulate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const accessLog = `
+203.0.113.7 - alice [01/Jan/2024:00:00:02 +0000] "GET /api/orders?page=2 HTTP/1.1" 200 512
+198.51.100.1 - - [01/Jan/2024:00:00:01 +0000] "POST /login HTTP/1.1" 401 12 "https://example.com/" "curl/8.0"
+203.0.113.7 - alice [01/Jan/2024:01:00:03 +0100] "GET /api/orders HTTP/1.1" 200 512
+`
+
+func TestSimulateReadCommonLog(t *testing.T) {
+	events, err := simulate.Read(strings.NewReader(accessLog), simulate.ReadOptions{})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, "198.51.100.1", events[0].Key, "events are sorted by time")
+	assert.True(t, clockStart.Add(time.Second).Equal(events[0].Time))
+	assert.Equal(t, "203.0.113.7", events[1].Key)
+	assert.True(t, clockStart.Add(3*time.Second).Equal(events[2].Time))
+
+	for key, want := range map[string][]string{
+		"user":    {"-", "alice", "alice"},
+		"path":    {"/login", "/api/orders", "/api/orders"},
+		"request": {"POST /login", "GET /api/orders", "GET /api/orders"},
+	} {
+		events, err := simulate.Read(strings.NewReader(accessLog), simulate.ReadOptions{Format: simulate.Common, Key: key})
+		require.NoError(t, err, key)
+		var got []string
+		for _, e := range events {
+			got = append(got, e.Key)
+		}
+		assert.Equal(t, want, got, key)
+	}
+
+	_, err = simulate.Read(strings.NewReader(accessLog+"garbage\n"), simulate.ReadOptions{})
+	require.ErrorContains(t, err, "line 5")
+	events, err = simulate.Read(strings.NewReader(accessLog+"garbage\n"), simulate.ReadOptions{SkipInvalid: true})
+	require.NoError(t, err)
+	assert.Len(t, events, 3)
+
+	_, err = simulate.Read(strings.NewReader(accessLog), simulate.ReadOptions{Key: "cookie"})
+	assert.ErrorIs(t, err, limiter.ErrInvalidConfig)
+}
+
+func TestSimulateReadJSONLines(t *testing.T) {
+	events, err := simulate.Read(strings.NewReader(`
+{"ts": "2024-01-01T00:00:01.5Z", "api_key": "a"}
+{"ts": 1704067200, "api_key": "b"}
+{"ts": 1704067200.25, "api_key": 42}
+`), simulate.ReadOptions{Format: simulate.JSONLines, Key: "api_key", TimeField: "ts"})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, []string{"b", "42", "a"}, []string{events[0].Key, events[1].Key, events[2].Key})
+	assert.True(t, clockStart.Add(250*time.Millisecond).Equal(events[1].Time))
+	assert.True(t, clockStart.Add(1500*time.Millisecond).Equal(events[2].Time))
+
+	_, err = simulate.Read(strings.NewReader(`{"timestamp": "2024-01-01T00:00:00Z"}`), simulate.ReadOptions{Format: simulate.JSONLines})
+	require.ErrorContains(t, err, `field "key" is missing`)
+	_, err = simulate.Read(strings.NewReader(`{"key": "a"}`), simulate.ReadOptions{Format: simulate.JSONLines})
+	require.ErrorContains(t, err, `field "timestamp" is not a time`)
+}
+
+func TestSimulateRunComparesBursts(t *testing.T) {
+	// One request opens the window, nine more straddle its end.
+	at := func(d time.Duration, n int) []simulate.Event {
+		events := make([]simulate.Event, n)
+		for i := range events {
+			events[i] = simulate.Event{Time: clockStart.Add(d), Key: "a"}
+		}
+		return events
+	}
+	var events []simulate.Event
+	events = append(events, at(0, 1)...)
+	events = append(events, at(59*time.Second, 4)...)
+	events = append(events, at(60*time.Second+time.Millisecond, 5)...)
+	events = append(events, at(119*time.Second, 1)...)
+	events = append(events, simulate.Event{Time: clockStart.Add(time.Second), Key: "b"})
+
+	run := func(algorithm limiter.Algorithm) *simulate.Report {
+		report, err := simulate.Run(events, limiter.Config{
+			MaxRequests: 5,
+			Window:      time.Minute,
+			Algorithm:   algorithm,
+			DryRun:      true,
+		})
+		require.NoError(t, err)
+		return report
+	}
+
+	fixed := run(limiter.FixedWindow)
+	assert.Equal(t, 12, fixed.Requests)
+	assert.Equal(t, 1, fixed.Denied)
+	assert.InDelta(t, 100.0/12, fixed.LimitedPercent(), 1e-9)
+	assert.Equal(t, 1, fixed.LimitedKeys())
+	assert.Equal(t, 9, fixed.PeakAdmitted(), "a fixed window admits a burst across its boundary")
+	assert.Equal(t, simulate.KeyReport{Key: "a", Requests: 11, Denied: 1, PeakDemand: 9, PeakAdmitted: 9}, fixed.Keys[0])
+	assert.Equal(t, simulate.KeyReport{Key: "b", Requests: 1, PeakDemand: 1, PeakAdmitted: 1}, fixed.Keys[1])
+
+	sliding := run(limiter.SlidingWindow)
+	assert.Equal(t, 4, sliding.Denied)
+	assert.Equal(t, 5, sliding.PeakAdmitted())
+	assert.Equal(t, 58*time.Second+999*time.Millisecond, sliding.Keys[0].LongestBlock)
+	assert.True(t, sliding.Config.DryRun, "the report keeps the configuration as given")
+
+	empty, err := simulate.Run(nil, limiter.Config{MaxRequests: 1, Window: time.Second, Algorithm: limiter.TokenBucket})
+	require.NoError(t, err)
+	assert.Zero(t, empty.LimitedPercent())
+
+	_, err = simulate.Run(events, limiter.Config{MaxRequests: 1, Window: time.Second, Algorithm: "leaky"})
+	assert.ErrorIs(t, err, limiter.ErrInvalidAlgorithm)
+}
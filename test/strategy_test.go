@@ -0,0 +1,190 @@
+package limiter_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	redisstore "github.com/NarmadaWeb/limiter/redis/v3"
+	"github.com/NarmadaWeb/limiter/v3"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// quotaStrategy is a fixed-window counter kept as "<count>:<resetMs>".
+type quotaStrategy struct{}
+
+const quotaAlgorithm limiter.Algorithm = "test-quota"
+
+func init() {
+	if err := limiter.RegisterStrategy(quotaStrategy{}); err != nil {
+		panic(err)
+	}
+}
+
+func (quotaStrategy) Name() limiter.Algorithm {
+	return quotaAlgorithm
+}
+
+func (quotaStrategy) Take(state []byte, now time.Time, n, maxRequests int, window time.Duration) limiter.StrategyResult {
+	count, resetMs := 0, int64(0)
+	if state != nil {
+		_, _ = fmt.Sscanf(string(state), "%d:%d", &count, &resetMs)
+	}
+
+	var ttl time.Duration
+	reset := time.UnixMilli(resetMs)
+	if state == nil || !now.Before(reset) {
+		count, reset, ttl = 0, now.Add(window), window
+	}
+
+	granted := max(min(n, maxRequests-count), 0)
+	count += granted
+	return limiter.StrategyResult{
+		Granted:   granted,
+		Remaining: maxRequests - count,
+		Reset:     reset,
+		State:     []byte(fmt.Sprintf("%d:%d", count, reset.UnixMilli())),
+		TTL:       ttl,
+	}
+}
+
+func (quotaStrategy) Rollback(state []byte, now time.Time) []byte {
+	count, resetMs := 0, int64(0)
+	_, _ = fmt.Sscanf(string(state), "%d:%d", &count, &resetMs)
+	if count <= 1 {
+		return nil
+	}
+	return []byte(fmt.Sprintf("%d:%d", count-1, resetMs))
+}
+
+func TestCustomStrategyRunsOnEveryStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	clock := miniredisClock{FakeClock: limiter.NewFakeClock(clockStart), mr: mr}
+	mr.SetTime(clockStart)
+
+	stores := map[string]limiter.Store{
+		"memory": limiter.NewMemoryStoreWithOptions(limiter.MemoryStoreOptions{Clock: clock}),
+		"redis": redisstore.NewStoreWithOptions(redis.NewClient(&redis.Options{Addr: mr.Addr()}),
+			redisstore.StoreOptions{Clock: clock}),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			take := func() (bool, int) {
+				allowed, remaining, _, err := store.Take(ctx, "client", 3, time.Minute, quotaAlgorithm)
+				require.NoError(t, err)
+				return allowed, remaining
+			}
+
+			for i := 0; i < 3; i++ {
+				allowed, remaining := take()
+				assert.True(t, allowed)
+				assert.Equal(t, 2-i, remaining)
+			}
+			allowed, _ := take()
+			assert.False(t, allowed)
+
+			require.NoError(t, store.Rollback(ctx, "client"))
+			allowed, _ = take()
+			assert.True(t, allowed)
+			allowed, _ = take()
+			assert.False(t, allowed)
+
+			clock.Advance(time.Minute)
+			allowed, remaining := take()
+			assert.True(t, allowed)
+			assert.Equal(t, 2, remaining)
+		})
+	}
+}
+
+func TestCustomStrategyConcurrentRedis(t *testing.T) {
+	_, client := newTestRedis(t)
+	store := redisstore.NewStore(client)
+
+	var admitted atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			allowed, _, _, err := store.Take(context.Background(), "shared", 20, time.Minute, quotaAlgorithm)
+			assert.NoError(t, err)
+			if allowed {
+				admitted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(20), admitted.Load())
+}
+
+func TestCustomStrategyMiddleware(t *testing.T) {
+	l, err := limiter.New(limiter.Config{
+		MaxRequests: 1,
+		Window:      time.Minute,
+		Algorithm:   quotaAlgorithm,
+	})
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	handler := l.StdLibMiddleware(limiter.StdLibConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func() int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve())
+	assert.Equal(t, http.StatusTooManyRequests, serve())
+}
+
+func TestUnknownAlgorithm(t *testing.T) {
+	_, err := limiter.New(limiter.Config{
+		MaxRequests: 1,
+		Window:      time.Minute,
+		Algorithm:   "leaky-bucket",
+	})
+	require.Error(t, err)
+
+	_, client := newTestRedis(t)
+	stores := map[string]limiter.Store{
+		"memory": limiter.NewMemoryStore(),
+		"redis":  redisstore.NewStore(client),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			_, _, _, err := store.Take(context.Background(), "client", 1, time.Minute, "leaky-bucket")
+			assert.ErrorIs(t, err, limiter.ErrInvalidAlgorithm)
+		})
+	}
+}
+
+func TestRegisterStrategyRejectsDuplicates(t *testing.T) {
+	assert.ErrorIs(t, limiter.RegisterStrategy(quotaStrategy{}), limiter.ErrInvalidAlgorithm)
+	assert.ErrorIs(t, limiter.RegisterStrategy(builtinStrategy{}), limiter.ErrInvalidAlgorithm)
+
+	assert.True(t, quotaAlgorithm.Valid())
+	assert.Contains(t, limiter.Algorithms(), quotaAlgorithm)
+	assert.False(t, quotaAlgorithm.Builtin())
+	assert.True(t, limiter.FixedWindow.Builtin())
+	assert.Contains(t, limiter.Strategies(), limiter.Strategy(quotaStrategy{}))
+}
+
+// builtinStrategy tries to replace a built-in algorithm.
+type builtinStrategy struct{ quotaStrategy }
+
+func (builtinStrategy) Name() limiter.Algorithm {
+	return limiter.FixedWindow
+}
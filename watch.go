@@ -0,0 +1,206 @@
+package limiter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+const defaultWatchInterval = time.Second
+
+// WatchOptions configures WatchFile and ApplyUpdate.
+type WatchOptions struct {
+	// Decode applies a document to cfg. Defaults to ApplyJSON.
+	Decode func(data []byte, cfg *Config) error
+	// Interval is how often WatchFile checks the file. Defaults to one second.
+	Interval time.Duration
+	// OnReload is called with the new configuration after every reload.
+	OnReload func(Config)
+	// OnError is called when a document cannot be read or applied. The
+	// limiter keeps its current configuration.
+	OnError func(error)
+}
+
+func (o *WatchOptions) setDefaults() {
+	if o.Decode == nil {
+		o.Decode = ApplyJSON
+	}
+	if o.Interval <= 0 {
+		o.Interval = defaultWatchInterval
+	}
+}
+
+// configPatch is the document read by ApplyJSON. Absent fields are left
+// unchanged.
+type configPatch struct {
+	MaxRequests   *int           `json:"max_requests"`
+	Window        *string        `json:"window"`
+	Algorithm     *Algorithm     `json:"algorithm"`
+	FailurePolicy *string        `json:"failure_policy"`
+	FallbackScale *float64       `json:"fallback_scale"`
+	DryRun        *bool          `json:"dry_run"`
+	Shadow        *[]shadowPatch `json:"shadow"`
+}
+
+type shadowPatch struct {
+	Name        string    `json:"name"`
+	MaxRequests int       `json:"max_requests"`
+	Window      string    `json:"window"`
+	Algorithm   Algorithm `json:"algorithm"`
+}
+
+// ApplyJSON applies a JSON document such as
+//
+//	{"max_requests": 500, "window": "1m", "algorithm": "sliding-window",
+//	 "failure_policy": "fail-open", "dry_run": false,
+//	 "shadow": [{"name": "strict", "max_requests": 100, "window": "1m"}]}
+//
+// to cfg. Absent fields keep their value and unknown fields are an error.
+// Windows use time.ParseDuration syntax.
+func ApplyJSON(data []byte, cfg *Config) error {
+	var patch configPatch
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&patch); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	errs := &ConfigError{}
+	next := *cfg
+	if patch.MaxRequests != nil {
+		next.MaxRequests = *patch.MaxRequests
+	}
+	if patch.Window != nil {
+		window, err := time.ParseDuration(*patch.Window)
+		if err != nil {
+			errs.add("window", err.Error(), nil)
+		}
+		next.Window = window
+	}
+	if patch.Algorithm != nil {
+		next.Algorithm = *patch.Algorithm
+	}
+	if patch.FailurePolicy != nil {
+		policy, err := ParseFailurePolicy(*patch.FailurePolicy)
+		if err != nil {
+			errs.add("failure_policy", fmt.Sprintf("unknown policy %q", *patch.FailurePolicy), nil)
+		}
+		next.FailurePolicy = policy
+	}
+	if patch.FallbackScale != nil {
+		next.FallbackScale = *patch.FallbackScale
+	}
+	if patch.DryRun != nil {
+		next.DryRun = *patch.DryRun
+	}
+	if patch.Shadow != nil {
+		next.Shadow = make([]ShadowLimit, len(*patch.Shadow))
+		for i, s := range *patch.Shadow {
+			window, err := time.ParseDuration(s.Window)
+			if err != nil {
+				errs.add(fmt.Sprintf("shadow[%d].window", i), err.Error(), nil)
+			}
+			next.Shadow[i] = ShadowLimit{Name: s.Name, MaxRequests: s.MaxRequests, Window: window, Algorithm: s.Algorithm}
+		}
+	}
+	if err := errs.orNil(); err != nil {
+		return err
+	}
+	*cfg = next
+	return nil
+}
+
+// WatchFile applies the document at path to the configuration in effect
+// now, then again whenever the file changes, until ctx is done or the
+// limiter is closed. The first load happens before WatchFile returns and
+// its error is returned; later errors go to opts.OnError.
+//
+// Every reload starts from the configuration WatchFile was called with, so
+// removing a field from the file restores its original value.
+func (l *Limiter) WatchFile(ctx context.Context, path string, opts WatchOptions) error {
+	opts.setDefaults()
+	base := l.Config()
+
+	load := func() (os.FileInfo, error) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return info, l.reload(base, data, opts)
+	}
+
+	last, err := load()
+	if err != nil {
+		return fmt.Errorf("watch %s: %w", path, err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-l.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				opts.onError(fmt.Errorf("watch %s: %w", path, err))
+				continue
+			}
+			if info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+				continue
+			}
+			if info, err = load(); err != nil {
+				opts.onError(fmt.Errorf("watch %s: %w", path, err))
+				continue
+			}
+			last = info
+		}
+	}()
+	return nil
+}
+
+// ApplyUpdate applies data to the configuration in effect now, decoding
+// it with opts.Decode, and calls opts.OnReload. Watchers of other sources,
+// such as the redis module's Watch, call it for every document received.
+func (l *Limiter) ApplyUpdate(data []byte, opts WatchOptions) error {
+	opts.setDefaults()
+	return l.reload(l.Config(), data, opts)
+}
+
+// Done returns a channel closed when the limiter is closed, which stops
+// its watchers.
+func (l *Limiter) Done() <-chan struct{} {
+	return l.ctx.Done()
+}
+
+// reload applies data on top of base and swaps the result in.
+func (l *Limiter) reload(base Config, data []byte, opts WatchOptions) error {
+	if err := opts.Decode(data, &base); err != nil {
+		return err
+	}
+	if err := l.UpdateConfig(base); err != nil {
+		return err
+	}
+	if opts.OnReload != nil {
+		opts.OnReload(l.Config())
+	}
+	return nil
+}
+
+func (o *WatchOptions) onError(err error) {
+	if o.OnError != nil {
+		o.OnError(err)
+	}
+}